@@ -0,0 +1,91 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ManagedByLabel is the label that identifies the Applications that are
+	// managed by the scanner.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+
+	// ManagedByScanner is the value of the ManagedByLabel for Applications
+	// that are managed by the scanner.
+	ManagedByScanner = "apps-scanner"
+)
+
+// ApplicationSpec is the desired state of an Application.
+//
+// Applications are discovered from the labels on resources, so there is
+// nothing to configure.
+type ApplicationSpec struct {
+}
+
+// KustomizationReference is a reference to a Flux Kustomization that
+// delivers an Application, with its health.
+type KustomizationReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// Health is the summary of the Ready condition of the Kustomization.
+	// +optional
+	Health string `json:"health,omitempty"`
+}
+
+// ApplicationStatus is the discovered state of an Application.
+type ApplicationStatus struct {
+	// Components are the values of the component label on the resources of
+	// the Application.
+	// +optional
+	Components []string `json:"components,omitempty"`
+
+	// Instances are the values of the instance label on the resources of the
+	// Application.
+	// +optional
+	Instances []string `json:"instances,omitempty"`
+
+	// Parents are the names of the Applications that this Application is part
+	// of.
+	// +optional
+	Parents []string `json:"parents,omitempty"`
+
+	// Kustomizations are the Flux Kustomizations that deliver the resources
+	// of the Application.
+	// +optional
+	Kustomizations []KustomizationReference `json:"kustomizations,omitempty"`
+
+	// Health is the least healthy of the Kustomizations, or Unknown if the
+	// Application is not delivered by Flux.
+	// +optional
+	Health string `json:"health,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=scannedapp
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Health",type=string,JSONPath=`.status.health`
+// +kubebuilder:printcolumn:name="Components",type=string,JSONPath=`.status.components`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Application is an application discovered from the labels on resources in
+// the cluster.
+type Application struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ApplicationSpec   `json:"spec,omitempty"`
+	Status ApplicationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ApplicationList contains a list of Application.
+type ApplicationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Application `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Application{}, &ApplicationList{})
+}
@@ -4,68 +4,88 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/argocd"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
 	"github.com/gitops-tools/apps-scanner/pkg/visualise"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	appsv1 "k8s.io/api/apps/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func newApplicationsCmd(cl client.Client) *cobra.Command {
+func newApplicationsCmd(newSources sourcesFunc) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "applications",
 		Short: "List applications in the cluster",
-		RunE:  listApplications(cl),
+		RunE:  listApplications(newSources),
 	}
 
 	cmd.Flags().String("graphviz-file", "", "Write a graphviz of the discovered applications")
+	cobra.CheckErr(cmd.Flags().MarkDeprecated("graphviz-file", "use --diagram-file with --diagram-format=dot"))
 	cobra.CheckErr(viper.BindPFlag("graphviz-file", cmd.Flags().Lookup("graphviz-file")))
 
+	addDiagramFlags(cmd, "applications")
+	cmd.Flags().Bool("diagram-detail", false, "Draw clusters, components, instances and Flux objects in DOT diagrams")
+	cobra.CheckErr(viper.BindPFlag("applications.diagram-detail", cmd.Flags().Lookup("diagram-detail")))
+
 	return cmd
 }
 
-func listApplications(cl client.Client) func(*cobra.Command, []string) error {
+func listApplications(newSources sourcesFunc) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		fmt.Println("Starting to scan for applications")
-		deploymentList := &appsv1.DeploymentList{}
-		err := cl.List(context.Background(), deploymentList, client.HasLabels([]string{applications.AppLabel}))
+		asJSON, err := jsonOutputRequested()
 		if err != nil {
-			return fmt.Errorf("failed to list deployments: %w", err)
+			return err
 		}
-		fmt.Printf("found %d deployments\n", len(deploymentList.Items))
-
-		p := applications.NewParser()
-		if err := p.Add(deploymentsToRuntimeObjects(deploymentList.Items...)); err != nil {
-			return fmt.Errorf("failed to discover applications: %w", err)
+		s, err := newScanner(newSources)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+
+		fmt.Fprintln(progressWriter(), "Starting to scan for applications")
+		apps, err := s.Applications(ctx)
+		failures := scanFailures(err)
+		if err := warnPartial(err); err != nil {
+			return err
 		}
 
-		apps := p.Applications()
-
-		for _, parent := range parentApps(apps) {
-			fmt.Printf("application %s\n", parent.Name)
-			for _, app := range childApps(apps, parent.Name) {
-				fmt.Printf("  child app: %s\n", app.Name)
-				fmt.Println("     instances:")
-				for _, e := range app.Instances {
-					fmt.Printf("         %s\n", e)
-				}
-				fmt.Println("     components:")
-				for _, s := range app.Components {
-					fmt.Printf("         %s\n", s)
-				}
+		if asJSON {
+			if err := inventory.WriteResult(os.Stdout, "applications", apps, scanner.NewCoverage(failures)); err != nil {
+				return err
+			}
+		} else {
+			argoApps, _, err := s.ArgoApplications(ctx)
+			failures = append(failures, scanFailures(err)...)
+			if err := warnPartial(err); err != nil {
+				return err
+			}
+			writeApplications(apps, argoApps)
+			writeCoverage(os.Stdout, scanner.NewCoverage(failures))
+		}
 
-				fmt.Println("      kustomizations:")
-				for _, s := range app.Kustomizations {
-					fmt.Printf("         %s\n", s)
-				}
+		var opts []visualise.DOTOption
+		if viper.GetBool("applications.diagram-detail") {
+			opts, err = detailedDOTOptions(ctx, s)
+			if err != nil {
+				return err
 			}
 		}
+		render := func(format string) (string, error) {
+			return visualise.RenderApplications(format, apps, opts...)
+		}
 
 		if filename := viper.GetString("graphviz-file"); filename != "" {
-			if err := writeGraph(apps, filename); err != nil {
+			if err := writeDiagram(filename, visualise.DOTFormat, render); err != nil {
+				return err
+			}
+		}
+
+		if filename := viper.GetString("applications.diagram-file"); filename != "" {
+			if err := writeDiagram(filename, viper.GetString("applications.diagram-format"), render); err != nil {
 				return err
 			}
 		}
@@ -73,12 +93,82 @@ func listApplications(cl client.Client) func(*cobra.Command, []string) error {
 	}
 }
 
-func writeGraph(apps []applications.Application, filename string) error {
-	graph := visualise.NewDOT(apps)
-	if err := os.WriteFile(filename, []byte(graph.String()), 0644); err != nil {
-		return err
+// detailedDOTOptions fetches the Flux objects from the cluster to draw
+// alongside the applications.
+func detailedDOTOptions(ctx context.Context, s *scanner.Scanner) ([]visualise.DOTOption, error) {
+	kustomizations, err := s.Kustomizations(ctx)
+	if err := warnPartial(err); err != nil {
+		return nil, err
+	}
+	repositories, err := s.GitRepositories(ctx)
+	if err := warnPartial(err); err != nil {
+		return nil, err
+	}
+
+	return []visualise.DOTOption{
+		visualise.WithClusters(),
+		visualise.WithComponents(),
+		visualise.WithFluxObjects(kustomizations, repositories),
+	}, nil
+}
+
+func writeApplications(apps []applications.Application, argoApps []argocd.Application) {
+	for _, parent := range parentApps(apps) {
+		fmt.Printf("application %s\n", parent.Name)
+		for _, app := range childApps(apps, parent.Name) {
+			fmt.Printf("  child app: %s\n", app.Name)
+			fmt.Println("     instances:")
+			for _, e := range app.Instances {
+				fmt.Printf("         %s\n", e)
+			}
+			fmt.Println("     components:")
+			for _, s := range app.Components {
+				fmt.Printf("         %s\n", s)
+			}
+
+			fmt.Println("      kustomizations:")
+			for _, s := range app.Kustomizations {
+				fmt.Printf("         %s\n", s)
+			}
+
+			if owners := argocd.Owners(app, argoApps); len(owners) > 0 {
+				fmt.Println("      argo cd applications:")
+				for _, v := range owners {
+					fmt.Printf("         %s %s\n", v.NamespacedName, describeArgoApplication(v))
+				}
+			}
+		}
 	}
-	return nil
+}
+
+// describeArgoApplication formats the sources and status of an Argo CD
+// Application e.g. "https://github.com/example/repo.git guestbook@HEAD
+// (Synced to 53e28ff, Healthy)".
+func describeArgoApplication(app argocd.Application) string {
+	var sources []string
+	for _, v := range app.Sources {
+		source := v.RepoURL
+		if v.Path != "" || v.Chart != "" {
+			source += " " + v.Path + v.Chart
+		}
+		if v.TargetRevision != "" {
+			source += "@" + v.TargetRevision
+		}
+		sources = append(sources, source)
+	}
+
+	status := app.SyncStatus
+	if status == "" {
+		status = "Unknown"
+	}
+	if app.Revision != "" {
+		status += " to " + app.Revision
+	}
+	if app.HealthStatus != "" {
+		status += ", " + app.HealthStatus
+	}
+
+	return fmt.Sprintf("%s (%s)", strings.Join(sources, ", "), status)
 }
 
 func parentApps(apps []applications.Application) []applications.Application {
@@ -109,12 +199,3 @@ func hasParentApplication(app applications.Application, parent string) bool {
 	}
 	return false
 }
-
-func deploymentsToRuntimeObjects(deploys ...appsv1.Deployment) []runtime.Object {
-	objs := make([]runtime.Object, len(deploys))
-	for i := range deploys {
-		objs[i] = &deploys[i]
-	}
-
-	return objs
-}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+	"github.com/gitops-tools/apps-scanner/pkg/policy"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+)
+
+// violationsExitCode is the exit code when there are violations of the
+// failing severity, which distinguishes them from failing to check.
+const violationsExitCode = 2
+
+func init() {
+	viper.SetDefault("policy.production-environments", []string{"production", "prod"})
+	viper.SetDefault("policy.staging-environments", []string{"staging"})
+}
+
+func newCheckCmd(newSources sourcesFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check the applications, pipelines and repositories against policy rules",
+		Long: `Evaluate the built-in rules, and rules from files, on each of the discovered
+applications, pipelines and repositories, and report the violations.
+
+Rules are CEL expressions that are true when an application, pipeline or
+repository complies with the rule. The stages of pipelines are also checked
+against the promotion conventions, from the refs and paths of the
+GitRepositories that are the sources of each stage.
+
+The command exits with code 2 if there are violations with the failing
+severity or a more severe one.`,
+		Args: cobra.NoArgs,
+		RunE: checkPolicy(newSources),
+	}
+
+	cmd.Flags().StringSlice("rules", nil, "Files of rules to evaluate as well as the built-in rules")
+	cobra.CheckErr(viper.BindPFlag("policy.rules", cmd.Flags().Lookup("rules")))
+
+	cmd.Flags().StringSlice("disable", nil, "Names of built-in rules and promotion conventions to disable")
+	cobra.CheckErr(viper.BindPFlag("policy.disable", cmd.Flags().Lookup("disable")))
+
+	cmd.Flags().String("fail-on", string(policy.SeverityError), "Severity of violations that fail the check, one of info, warning or error")
+	cobra.CheckErr(viper.BindPFlag("policy.fail-on", cmd.Flags().Lookup("fail-on")))
+
+	return cmd
+}
+
+func checkPolicy(newSources sourcesFunc) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		asJSON, err := jsonOutputRequested()
+		if err != nil {
+			return err
+		}
+		failOn, err := policy.ParseSeverity(viper.GetString("policy.fail-on"))
+		if err != nil {
+			return err
+		}
+		engine, err := newPolicyEngine()
+		if err != nil {
+			return err
+		}
+		s, err := newScanner(newSources)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+
+		fmt.Fprintln(progressWriter(), "Starting to scan for the inventory")
+		inv, err := s.Inventory(ctx)
+		failures := scanFailures(err)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+		kustomizations, err := s.PipelineKustomizations(ctx)
+		failures = append(failures, scanFailures(err)...)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+		repositories, err := s.GitRepositories(ctx)
+		failures = append(failures, scanFailures(err)...)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+
+		sources := map[string][]pipelines.StageSource{}
+		for _, pl := range inv.Pipelines {
+			sources[pl.Name] = pipelines.StageSources(s.PipelineStages(pl, kustomizations), repositories)
+		}
+		violations, err := engine.Evaluate(policy.Input{
+			Applications: inv.Applications,
+			Pipelines:    inv.Pipelines,
+			Repositories: inv.Repositories,
+			Environments: pipelines.SourceEnvironments(pipelineLabels(), kustomizations, repositories),
+			Sources:      sources,
+		})
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			if err := inventory.WriteResult(os.Stdout, "violations", violations, scanner.NewCoverage(failures)); err != nil {
+				return err
+			}
+		} else {
+			if err := writeViolations(os.Stdout, violations); err != nil {
+				return err
+			}
+			writeCoverage(os.Stdout, scanner.NewCoverage(failures))
+		}
+		if policy.Failed(violations, failOn) {
+			// This isn't a usage error, so the usage is not useful.
+			cmd.SilenceUsage = true
+			return &exitError{code: violationsExitCode, err: fmt.Errorf("policy violations with severity %s or more", failOn)}
+		}
+		return nil
+	}
+}
+
+// newPolicyEngine creates an Engine for the built-in rules and promotion
+// conventions that are not disabled, and the rules in the configured files.
+func newPolicyEngine() (*policy.Engine, error) {
+	disabled := viper.GetStringSlice("policy.disable")
+	rules, err := policy.EnabledBuiltinRules(disabled...)
+	if err != nil {
+		return nil, err
+	}
+	for _, filename := range viper.GetStringSlice("policy.rules") {
+		fileRules, err := policy.ReadRulesFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, fileRules...)
+	}
+	return policy.New(rules,
+		policy.WithProduction(viper.GetStringSlice("policy.production-environments")...),
+		policy.WithStaging(viper.GetStringSlice("policy.staging-environments")...),
+		policy.WithoutConventions(disabled...))
+}
+
+// writeViolations writes a table of the violations, and the number of
+// violations of each severity.
+func writeViolations(w io.Writer, violations []policy.Violation) error {
+	if len(violations) == 0 {
+		fmt.Fprintln(w, "no violations")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEVERITY\tRULE\tKIND\tNAME\tMESSAGE")
+	counts := map[policy.Severity]int{}
+	for _, v := range violations {
+		counts[v.Severity]++
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", v.Severity, v.Rule, v.Kind, v.Name, v.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%d violations (error: %d, warning: %d, info: %d)\n", len(violations),
+		counts[policy.SeverityError], counts[policy.SeverityWarning], counts[policy.SeverityInfo])
+	return nil
+}
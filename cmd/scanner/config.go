@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+)
+
+const (
+	// configName is the name of the configuration file without an extension.
+	configName = ".apps-scanner"
+
+	// envPrefix is the prefix for environment variables that override the
+	// configuration e.g. APPS_SCANNER_NAMESPACES.
+	envPrefix = "APPS_SCANNER"
+)
+
+func init() {
+	appLabels := applications.NewParser().Labels
+	viper.SetDefault("applications.labels.name", appLabels.Name)
+	viper.SetDefault("applications.labels.part-of", appLabels.PartOf)
+	viper.SetDefault("applications.labels.instance", appLabels.Instance)
+	viper.SetDefault("applications.labels.component", appLabels.Component)
+
+	pipelineLabels := pipelines.NewParser().Labels
+	viper.SetDefault("pipelines.labels.pipeline", pipelineLabels.Pipeline)
+	viper.SetDefault("pipelines.labels.environment", pipelineLabels.Environment)
+	viper.SetDefault("pipelines.labels.after", pipelineLabels.After)
+}
+
+// initConfig reads the configuration file, and configures the overrides from
+// the environment.
+//
+// If no file is provided, the configuration is discovered in the current
+// directory, the XDG config directory and the home directory in that order.
+// It's not an error if no configuration is discovered.
+func initConfig(filename string) error {
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	viper.AutomaticEnv()
+
+	if filename != "" {
+		viper.SetConfigFile(filename)
+		if err := viper.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read configuration: %w", err)
+		}
+		return nil
+	}
+
+	viper.SetConfigName(configName)
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(".")
+	if dir, err := os.UserConfigDir(); err == nil {
+		viper.AddConfigPath(filepath.Join(dir, "apps-scanner"))
+	}
+	if dir, err := os.UserHomeDir(); err == nil {
+		viper.AddConfigPath(dir)
+	}
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to read configuration: %w", err)
+	}
+	return nil
+}
+
+func applicationLabels() applications.Labels {
+	return applications.Labels{
+		Name:      viper.GetString("applications.labels.name"),
+		PartOf:    viper.GetString("applications.labels.part-of"),
+		Instance:  viper.GetString("applications.labels.instance"),
+		Component: viper.GetString("applications.labels.component"),
+	}
+}
+
+func pipelineLabels() pipelines.Labels {
+	return pipelines.Labels{
+		Pipeline:    viper.GetString("pipelines.labels.pipeline"),
+		Environment: viper.GetString("pipelines.labels.environment"),
+		After:       viper.GetString("pipelines.labels.after"),
+	}
+}
+
+// configuredEnvironments returns how the environments of instances are
+// identified, or nil if no environments are configured.
+func configuredEnvironments() (*applications.Environments, error) {
+	envs := applications.Environments{
+		Label:     viper.GetString("environments.label"),
+		Pipelines: viper.GetBool("environments.from-pipelines"),
+		Cluster:   viper.GetBool("environments.from-cluster"),
+	}
+	if pattern := viper.GetString("environments.namespace-pattern"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse environments namespace pattern: %w", err)
+		}
+		envs.Namespace = re
+	}
+	if envs.Label == "" && !envs.Pipelines && !envs.Cluster && envs.Namespace == nil {
+		return nil, nil
+	}
+	return &envs, nil
+}
+
+func configuredKinds() []schema.GroupKind {
+	var kinds []schema.GroupKind
+	for _, v := range viper.GetStringSlice("kinds") {
+		kinds = append(kinds, schema.ParseGroupKind(v))
+	}
+	return kinds
+}
+
+// scannerOptions configures a Scanner from the configuration.
+func scannerOptions() []scanner.Option {
+	return []scanner.Option{
+		scanner.WithKinds(configuredKinds()...),
+		scanner.WithNamespaces(viper.GetStringSlice("namespaces")...),
+		scanner.WithApplicationLabels(applicationLabels()),
+		scanner.WithPipelineLabels(pipelineLabels()),
+		scanner.WithProgress(progressWriter()),
+		scanner.WithConcurrency(viper.GetInt("concurrency")),
+	}
+}
+
+// newScanner creates a Scanner for the configured sources.
+//
+// The options are applied after the configuration.
+func newScanner(newSources sourcesFunc, opts ...scanner.Option) (*scanner.Scanner, error) {
+	sources, err := newSources()
+	if err != nil {
+		return nil, err
+	}
+	envs, err := configuredEnvironments()
+	if err != nil {
+		return nil, err
+	}
+
+	scannerOpts := scannerOptions()
+	if envs != nil {
+		scannerOpts = append(scannerOpts, scanner.WithEnvironments(*envs))
+	}
+	for _, src := range sources {
+		scannerOpts = append(scannerOpts, scanner.WithSource(src))
+	}
+	return scanner.New(append(scannerOpts, opts...)...), nil
+}
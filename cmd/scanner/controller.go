@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/gitops-tools/apps-scanner/pkg/controller"
+)
+
+// leaderElectionID is the name of the lease that replicas of the controller
+// elect a leader with.
+const leaderElectionID = "apps-scanner.apps.gitops.pro"
+
+func newControllerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "controller",
+		Short: "Reconcile the discovered applications into Application resources",
+		Long: `Run a controller that keeps a cluster-scoped Application resource up to date
+for each of the applications discovered in the cluster.
+
+The Application CRD must be installed from deploy/crd, or the controller
+deployed in the cluster from deploy.`,
+		Args: cobra.NoArgs,
+		RunE: runController,
+	}
+
+	cmd.Flags().String("metrics-bind-address", ":8080", "The address the metrics endpoint binds to, 0 disables the endpoint")
+	cobra.CheckErr(viper.BindPFlag("controller.metrics-bind-address", cmd.Flags().Lookup("metrics-bind-address")))
+	cmd.Flags().String("health-probe-bind-address", ":8081", "The address the liveness and readiness endpoints bind to, 0 disables the endpoints")
+	cobra.CheckErr(viper.BindPFlag("controller.health-probe-bind-address", cmd.Flags().Lookup("health-probe-bind-address")))
+	cmd.Flags().Bool("leader-elect", false, "Elect a leader so that only one of the replicas of the controller is active")
+	cobra.CheckErr(viper.BindPFlag("controller.leader-elect", cmd.Flags().Lookup("leader-elect")))
+	cmd.Flags().String("leader-election-namespace", "", "Namespace of the leader election lease, defaults to the namespace the controller runs in")
+	cobra.CheckErr(viper.BindPFlag("controller.leader-election-namespace", cmd.Flags().Lookup("leader-election-namespace")))
+
+	return cmd
+}
+
+func runController(cmd *cobra.Command, args []string) error {
+	ctrl.SetLogger(zap.New())
+
+	cfg, err := restConfig("")
+	if err != nil {
+		return err
+	}
+
+	cacheOptions := cache.Options{}
+	if namespaces := viper.GetStringSlice("namespaces"); len(namespaces) > 0 {
+		cacheOptions.DefaultNamespaces = map[string]cache.Config{}
+		for _, ns := range namespaces {
+			cacheOptions.DefaultNamespaces[ns] = cache.Config{}
+		}
+	}
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:                  scheme,
+		Metrics:                 metricsserver.Options{BindAddress: viper.GetString("controller.metrics-bind-address")},
+		HealthProbeBindAddress:  viper.GetString("controller.health-probe-bind-address"),
+		LeaderElection:          viper.GetBool("controller.leader-elect"),
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: viper.GetString("controller.leader-election-namespace"),
+		Cache:                   cacheOptions,
+		Client: client.Options{
+			// The scanned kinds are read as unstructured resources.
+			Cache: &client.CacheOptions{Unstructured: true},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create manager: %w", err)
+	}
+
+	var kinds []schema.GroupVersionKind
+	for _, gk := range configuredKinds() {
+		mapping, err := mgr.GetRESTMapper().RESTMapping(gk)
+		if err != nil {
+			return fmt.Errorf("failed to find kind %s: %w", gk, err)
+		}
+		kinds = append(kinds, mapping.GroupVersionKind)
+	}
+
+	if err := (&controller.ApplicationReconciler{
+		Client: mgr.GetClient(),
+		Kinds:  kinds,
+		Labels: applicationLabels(),
+	}).SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("failed to create application controller: %w", err)
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		return fmt.Errorf("failed to add liveness check: %w", err)
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		return fmt.Errorf("failed to add readiness check: %w", err)
+	}
+
+	return mgr.Start(ctrl.SetupSignalHandler())
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gitops-tools/apps-scanner/pkg/visualise"
+)
+
+// addDiagramFlags adds the flags for writing diagrams to the command, binding
+// them to configuration keys prefixed with the provided prefix.
+func addDiagramFlags(cmd *cobra.Command, prefix string) {
+	cmd.Flags().String("diagram-file", "", "Write a diagram of the discovered "+prefix+" to this file")
+	cobra.CheckErr(viper.BindPFlag(prefix+".diagram-file", cmd.Flags().Lookup("diagram-file")))
+
+	cmd.Flags().String("diagram-format", visualise.DOTFormat, "Format of the diagram, one of "+strings.Join(visualise.Formats, ", "))
+	cobra.CheckErr(viper.BindPFlag(prefix+".diagram-format", cmd.Flags().Lookup("diagram-format")))
+}
+
+// writeDiagram renders a diagram in the requested format and writes it to the
+// named file.
+func writeDiagram(filename, format string, render func(format string) (string, error)) error {
+	diagram, err := render(format)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, []byte(diagram), 0644); err != nil {
+		return fmt.Errorf("failed to write diagram: %w", err)
+	}
+	return nil
+}
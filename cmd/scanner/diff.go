@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+)
+
+func newDiffCmd(newSources sourcesFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <old.json> [new.json]",
+		Short: "Compare two scans and report the changes to the inventory",
+		Long: `Compare two inventories written with "report --json" and report the
+added and removed applications, changes to components, instances, parents and
+Kustomizations, pipeline stage changes and GitRepository ref changes.
+
+With --against, the inventory is compared against the live cluster.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: diffInventories(newSources),
+	}
+
+	cmd.Flags().Bool("against", false, "Compare the inventory file against the live cluster")
+	cobra.CheckErr(viper.BindPFlag("diff.against", cmd.Flags().Lookup("against")))
+
+	cmd.Flags().Bool("exit-code", false, "Exit with an error if there are changes")
+	cobra.CheckErr(viper.BindPFlag("diff.exit-code", cmd.Flags().Lookup("exit-code")))
+
+	return cmd
+}
+
+func diffInventories(newSources sourcesFunc) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		against := viper.GetBool("diff.against")
+		if against && len(args) != 1 {
+			return fmt.Errorf("only one inventory file can be compared against the cluster")
+		}
+		if !against && len(args) != 2 {
+			return fmt.Errorf("two inventory files are required, or use --against")
+		}
+
+		old, err := inventory.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		var new *inventory.Inventory
+		if against {
+			s, err := newScanner(newSources)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+			// An incomplete scan would be reported as removals, so it fails.
+			new, err = s.Inventory(ctx)
+			if err != nil {
+				return err
+			}
+		} else {
+			new, err = inventory.ReadFile(args[1])
+			if err != nil {
+				return err
+			}
+		}
+
+		changes := inventory.Diff(*old, *new)
+		writeChanges(os.Stdout, changes)
+		if viper.GetBool("diff.exit-code") && !changes.Empty() {
+			// This isn't a usage error, so the usage is not useful.
+			cmd.SilenceUsage = true
+			return fmt.Errorf("inventories differ")
+		}
+		return nil
+	}
+}
+
+func writeChanges(w io.Writer, c inventory.Changes) {
+	if c.Empty() {
+		fmt.Fprintln(w, "no changes")
+		return
+	}
+
+	for _, v := range c.AddedApplications {
+		fmt.Fprintf(w, "+ application %s\n", v)
+	}
+	for _, v := range c.RemovedApplications {
+		fmt.Fprintf(w, "- application %s\n", v)
+	}
+	for _, v := range c.Applications {
+		fmt.Fprintf(w, "~ application %s\n", v.Name)
+		writeMembers(w, "component", v.AddedComponents, v.RemovedComponents)
+		writeMembers(w, "instance", v.AddedInstances, v.RemovedInstances)
+		writeMembers(w, "parent", v.AddedParents, v.RemovedParents)
+		writeMembers(w, "kustomization", v.AddedKustomizations, v.RemovedKustomizations)
+	}
+
+	for _, v := range c.AddedPipelines {
+		fmt.Fprintf(w, "+ pipeline %s\n", v)
+	}
+	for _, v := range c.RemovedPipelines {
+		fmt.Fprintf(w, "- pipeline %s\n", v)
+	}
+	for _, v := range c.Pipelines {
+		change := "changed"
+		if v.Reordered {
+			change = "reordered"
+		}
+		fmt.Fprintf(w, "~ pipeline %s %s: %s -> %s\n", v.Name, change,
+			strings.Join(v.OldEnvironments, ","), strings.Join(v.NewEnvironments, ","))
+	}
+
+	for _, v := range c.Repositories {
+		switch {
+		case v.OldRef == nil:
+			fmt.Fprintf(w, "+ gitrepository %s %s\n", v.NamespacedName, describeRef(v.NewRef))
+		case v.NewRef == nil:
+			fmt.Fprintf(w, "- gitrepository %s %s\n", v.NamespacedName, describeRef(v.OldRef))
+		default:
+			fmt.Fprintf(w, "~ gitrepository %s: %s -> %s\n", v.NamespacedName, describeRef(v.OldRef), describeRef(v.NewRef))
+		}
+	}
+}
+
+func writeMembers(w io.Writer, kind string, added, removed []string) {
+	for _, v := range added {
+		fmt.Fprintf(w, "    + %s %s\n", kind, v)
+	}
+	for _, v := range removed {
+		fmt.Fprintf(w, "    - %s %s\n", kind, v)
+	}
+}
+
+func describeRef(ref *sourcev1.GitRepositoryRef) string {
+	if s := flux.DescribeRef(*ref); s != "" {
+		return s
+	}
+	return "(default branch)"
+}
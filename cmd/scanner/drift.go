@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/manifests"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+)
+
+func newDriftCmd(newSources sourcesFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drift <path>",
+		Short: "Compare the applications in rendered manifests with the cluster",
+		Long: `Compare the applications discovered in rendered manifests with the
+applications discovered in the cluster.
+
+The path can be a file, or a directory of YAML or JSON manifests, or "-" to
+read the output of "kustomize build" from stdin.`,
+		Args: cobra.ExactArgs(1),
+		RunE: detectDrift(newSources),
+	}
+
+	cmd.Flags().Bool("exit-code", false, "Exit with an error if there is drift")
+	cobra.CheckErr(viper.BindPFlag("drift.exit-code", cmd.Flags().Lookup("exit-code")))
+
+	return cmd
+}
+
+func detectDrift(newSources sourcesFunc) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		objs, err := readManifests(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println("Starting to scan the manifests for applications")
+		desired, err := scanner.New(scannerOptions()...).ApplicationsFromObjects(objs)
+		if err != nil {
+			return err
+		}
+
+		s, err := newScanner(newSources)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		fmt.Println("Starting to scan for applications")
+		// An incomplete scan would be reported as drift, so it fails.
+		live, err := s.Applications(ctx)
+		if err != nil {
+			return err
+		}
+
+		changes := inventory.Diff(
+			inventory.Inventory{Applications: withoutDeliveryRefs(desired)},
+			inventory.Inventory{Applications: withoutDeliveryRefs(live)})
+		writeDrift(os.Stdout, changes)
+		if viper.GetBool("drift.exit-code") && !changes.Empty() {
+			// This isn't a usage error, so the usage is not useful.
+			cmd.SilenceUsage = true
+			return fmt.Errorf("applications have drifted")
+		}
+		return nil
+	}
+}
+
+func readManifests(path string) ([]unstructured.Unstructured, error) {
+	if path == "-" {
+		return manifests.Read(os.Stdin)
+	}
+	return manifests.ReadPath(path)
+}
+
+// withoutDeliveryRefs removes the Kustomization and Argo CD Application
+// references from the Applications.
+//
+// The references come from labels and annotations that Flux and Argo CD add
+// when applying the manifests, so they are never in the rendered manifests.
+func withoutDeliveryRefs(apps []applications.Application) []applications.Application {
+	res := make([]applications.Application, len(apps))
+	for i, app := range apps {
+		app.Kustomizations = nil
+		app.ArgoApplications = nil
+		res[i] = app
+	}
+	return res
+}
+
+func writeDrift(w io.Writer, c inventory.Changes) {
+	if c.Empty() {
+		fmt.Fprintln(w, "no drift")
+		return
+	}
+
+	for _, v := range c.RemovedApplications {
+		fmt.Fprintf(w, "application %s only in git\n", v)
+	}
+	for _, v := range c.AddedApplications {
+		fmt.Fprintf(w, "application %s only in the cluster\n", v)
+	}
+	for _, v := range c.Applications {
+		fmt.Fprintf(w, "application %s differs\n", v.Name)
+		writeDriftedMembers(w, "component", v.AddedComponents, v.RemovedComponents)
+		writeDriftedMembers(w, "instance", v.AddedInstances, v.RemovedInstances)
+		writeDriftedMembers(w, "parent", v.AddedParents, v.RemovedParents)
+	}
+}
+
+func writeDriftedMembers(w io.Writer, kind string, inCluster, inGit []string) {
+	for _, v := range inGit {
+		fmt.Fprintf(w, "    %s %s only in git\n", kind, v)
+	}
+	for _, v := range inCluster {
+		fmt.Fprintf(w, "    %s %s only in the cluster\n", kind, v)
+	}
+}
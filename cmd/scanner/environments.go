@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+)
+
+func newEnvironmentsCmd(newSources sourcesFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "environments",
+		Short: "List the environments that each application is deployed to",
+		Long: `List the applications and the components that are deployed to each of the
+environments.
+
+The environments are identified from the configuration, a label on the
+resources, the environment label of the pipelines that deliver them, a naming
+convention for namespaces, or the names of the scanned contexts.`,
+		Args: cobra.NoArgs,
+		RunE: listEnvironments(newSources),
+	}
+
+	cmd.Flags().StringSlice("order", nil, "Order of the environments, others are listed after these in alphabetical order")
+	cobra.CheckErr(viper.BindPFlag("environments.order", cmd.Flags().Lookup("order")))
+
+	return cmd
+}
+
+func listEnvironments(newSources sourcesFunc) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		asJSON, err := jsonOutputRequested()
+		if err != nil {
+			return err
+		}
+		envs, err := configuredEnvironments()
+		if err != nil {
+			return err
+		}
+		if envs == nil {
+			return errors.New("no environments are configured, configure environments.label, environments.from-pipelines, environments.namespace-pattern or environments.from-cluster")
+		}
+		s, err := newScanner(newSources)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+
+		fmt.Fprintln(progressWriter(), "Starting to scan for applications")
+		apps, err := s.Applications(ctx)
+		failures := scanFailures(err)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+
+		matrix := applications.NewMatrix(apps, viper.GetStringSlice("environments.order")...)
+		if asJSON {
+			return inventory.WriteResult(os.Stdout, "environments", matrix, scanner.NewCoverage(failures))
+		}
+		if err := writeMatrix(os.Stdout, matrix); err != nil {
+			return err
+		}
+		writeCoverage(os.Stdout, scanner.NewCoverage(failures))
+		return nil
+	}
+}
+
+// writeMatrix writes a table of the applications with the components that are
+// deployed to each of the environments.
+func writeMatrix(w io.Writer, m applications.Matrix) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "APPLICATION\t%s\n", strings.Join(m.Environments, "\t"))
+	for _, row := range m.Rows {
+		cells := make([]string, len(row.Cells))
+		for i, v := range row.Cells {
+			cells[i] = describeEnvironment(v)
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", row.Application, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// describeEnvironment formats the components of an application in an
+// environment, "-" if the application is not deployed to the environment.
+func describeEnvironment(env *applications.Environment) string {
+	if env == nil {
+		return "-"
+	}
+	var components []string
+	for _, v := range env.Components {
+		if v != "" {
+			components = append(components, v)
+		}
+	}
+	if len(components) == 0 {
+		return "deployed"
+	}
+	return strings.Join(components, ",")
+}
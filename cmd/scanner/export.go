@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gitops-tools/apps-scanner/pkg/backstage"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+)
+
+func newExportCmd(newSources sourcesFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the discovered applications to other tools",
+	}
+	cmd.AddCommand(newExportBackstageCmd(newSources))
+
+	return cmd
+}
+
+func newExportBackstageCmd(newSources sourcesFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backstage",
+		Short: "Export the applications as Backstage catalog entities",
+		Long: `Export the discovered applications as Backstage catalog-info.yaml entities.
+
+Top-level applications with children are exported as Systems, and every other
+application is exported as a Component with annotations for the Backstage
+Kubernetes plugin.`,
+		Args: cobra.NoArgs,
+		RunE: exportBackstage(newSources),
+	}
+
+	cmd.Flags().String("owner", "unknown", "Owner of the exported entities")
+	cobra.CheckErr(viper.BindPFlag("export.backstage.owner", cmd.Flags().Lookup("owner")))
+	cmd.Flags().String("lifecycle", "production", "Lifecycle of the exported Components")
+	cobra.CheckErr(viper.BindPFlag("export.backstage.lifecycle", cmd.Flags().Lookup("lifecycle")))
+	cmd.Flags().String("type", "service", "Type of the exported Components")
+	cobra.CheckErr(viper.BindPFlag("export.backstage.type", cmd.Flags().Lookup("type")))
+	cmd.Flags().String("file", "", "Write the entities to this file rather than stdout e.g. catalog-info.yaml")
+	cobra.CheckErr(viper.BindPFlag("export.backstage.file", cmd.Flags().Lookup("file")))
+
+	return cmd
+}
+
+func exportBackstage(newSources sourcesFunc) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		// Keep stdout for the exported entities.
+		s, err := newScanner(newSources, scanner.WithProgress(os.Stderr))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		apps, err := s.Applications(ctx)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+
+		entities := backstage.Entities(apps, backstage.Options{
+			Owner:     viper.GetString("export.backstage.owner"),
+			Lifecycle: viper.GetString("export.backstage.lifecycle"),
+			Type:      viper.GetString("export.backstage.type"),
+			NameLabel: applicationLabels().Name,
+		})
+
+		filename := viper.GetString("export.backstage.file")
+		if filename == "" {
+			return backstage.Write(os.Stdout, entities)
+		}
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		if err := backstage.Write(f, entities); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/history"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+)
+
+const dateFormat = "2006-01-02"
+
+func newHistoryCmd(newSources sourcesFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Record and query snapshots of the inventory",
+	}
+
+	cmd.PersistentFlags().String("history-dir", defaultHistoryDir(), "Directory to record snapshots in")
+	cobra.CheckErr(viper.BindPFlag("history.dir", cmd.PersistentFlags().Lookup("history-dir")))
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "record [inventory.json]",
+		Short: "Record a snapshot of the cluster, or of an inventory written with \"report --json\"",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  recordSnapshot(newSources),
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the recorded snapshots",
+		Args:  cobra.NoArgs,
+		RunE:  listSnapshots,
+	})
+
+	showCmd := &cobra.Command{
+		Use:   "show <application>",
+		Short: "Show an application, and its children, as it was at a point in time",
+		Args:  cobra.ExactArgs(1),
+		RunE:  showApplicationHistory,
+	}
+	showCmd.Flags().String("at", "", "Date (2006-01-02) or time (RFC3339) to query, defaults to the latest snapshot")
+	cobra.CheckErr(viper.BindPFlag("history.at", showCmd.Flags().Lookup("at")))
+	cmd.AddCommand(showCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "first-seen <name>",
+		Short: "Show when an application, component or instance first appeared",
+		Args:  cobra.ExactArgs(1),
+		RunE:  firstSeen,
+	})
+
+	return cmd
+}
+
+func recordSnapshot(newSources sourcesFunc) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		var inv *inventory.Inventory
+		var err error
+		if len(args) == 1 {
+			inv, err = inventory.ReadFile(args[0])
+		} else {
+			inv, err = scanClusterInventory(cmd, newSources)
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := historyStore().Save(now, *inv); err != nil {
+			return err
+		}
+		fmt.Printf("recorded snapshot at %s\n", now.UTC().Format(time.RFC3339))
+		return nil
+	}
+}
+
+func listSnapshots(cmd *cobra.Command, args []string) error {
+	times, err := historyStore().Times()
+	if err != nil {
+		return err
+	}
+	for _, t := range times {
+		fmt.Println(t.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func showApplicationHistory(cmd *cobra.Command, args []string) error {
+	at := time.Now()
+	if s := viper.GetString("history.at"); s != "" {
+		t, err := parseHistoryTime(s)
+		if err != nil {
+			return err
+		}
+		at = t
+	}
+
+	snapshot, err := historyStore().At(at)
+	if err != nil {
+		return err
+	}
+
+	name := args[0]
+	apps := snapshot.Inventory.Applications
+	found := false
+	for _, app := range apps {
+		if app.Name == name || hasParentApplication(app, name) {
+			found = true
+			printApplicationSnapshot(app)
+		}
+	}
+	if !found {
+		return fmt.Errorf("application %q not found in the snapshot at %s", name, snapshot.Time.Format(time.RFC3339))
+	}
+	fmt.Printf("from the snapshot at %s\n", snapshot.Time.Format(time.RFC3339))
+	return nil
+}
+
+func printApplicationSnapshot(app applications.Application) {
+	fmt.Printf("application %s\n", app.Name)
+	if len(app.Components) > 0 {
+		fmt.Printf("  components: %s\n", strings.Join(app.Components, ","))
+	}
+	if len(app.Instances) > 0 {
+		fmt.Printf("  instances: %s\n", strings.Join(app.Instances, ","))
+	}
+}
+
+func firstSeen(cmd *cobra.Command, args []string) error {
+	sighting, err := historyStore().FirstSeen(args[0])
+	if err != nil {
+		return err
+	}
+
+	if sighting.Kind == "application" {
+		fmt.Printf("application %s first seen at %s\n", args[0], sighting.Time.Format(time.RFC3339))
+		return nil
+	}
+	fmt.Printf("%s %s of application %s first seen at %s\n", sighting.Kind, args[0], sighting.Application, sighting.Time.Format(time.RFC3339))
+	return nil
+}
+
+func historyStore() *history.Store {
+	return history.NewStore(viper.GetString("history.dir"))
+}
+
+// parseHistoryTime parses either a date, which is treated as the end of the
+// day in UTC, or an RFC3339 time.
+func parseHistoryTime(s string) (time.Time, error) {
+	if d, err := time.Parse(dateFormat, s); err == nil {
+		return d.Add(24*time.Hour - time.Second), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, must be a date (%s) or RFC3339 time", s, dateFormat)
+	}
+	return t, nil
+}
+
+// defaultHistoryDir returns the directory to record snapshots in, following
+// the XDG base directory conventions.
+func defaultHistoryDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "apps-scanner", "history")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".apps-scanner", "history")
+	}
+	return filepath.Join(home, ".local", "share", "apps-scanner", "history")
+}
+
+// scanClusterInventory scans the inventory to record, an incomplete scan would
+// be recorded as removals, so it fails.
+func scanClusterInventory(cmd *cobra.Command, newSources sourcesFunc) (*inventory.Inventory, error) {
+	s, err := newScanner(newSources)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := commandContext(cmd)
+	defer cancel()
+	fmt.Println("Starting to scan for the inventory")
+	return s.Inventory(ctx)
+}
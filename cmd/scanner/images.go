@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gitops-tools/apps-scanner/pkg/images"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+)
+
+func newImagesCmd(newSources sourcesFunc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "images",
+		Short: "List the container images of the applications",
+		Long: `List the container images in the pod templates of the workloads of each
+application and component, whether each image is pinned by a digest or a tag,
+the registries that images are pulled from, and the images that are shared
+by more than one application.`,
+		Args: cobra.NoArgs,
+		RunE: listImages(newSources),
+	}
+}
+
+func listImages(newSources sourcesFunc) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		asJSON, err := jsonOutputRequested()
+		if err != nil {
+			return err
+		}
+		s, err := newScanner(newSources)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+
+		fmt.Fprintln(progressWriter(), "Starting to scan for images")
+		usages, err := s.Images(ctx)
+		failures := scanFailures(err)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+
+		report := images.NewReport(usages)
+		if asJSON {
+			return inventory.WriteResult(os.Stdout, "images", report, scanner.NewCoverage(failures))
+		}
+		if err := writeImagesReport(os.Stdout, report); err != nil {
+			return err
+		}
+		writeCoverage(os.Stdout, scanner.NewCoverage(failures))
+		return nil
+	}
+}
+
+// writeImagesReport writes tables of the images of the applications, the
+// registries and the shared images.
+func writeImagesReport(w io.Writer, r images.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "APPLICATION\tCOMPONENT\tCONTAINER\tIMAGE\tPINNED BY")
+	for _, img := range r.Images {
+		component := img.Component
+		if component == "" {
+			component = "-"
+		}
+		container := img.Container
+		if img.Init {
+			container += " (init)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", img.Application, component, container, img.Image, img.Pinning)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REGISTRY\tIMAGES\tDIGEST\tTAG\tUNPINNED")
+	for _, reg := range r.Registries {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", reg.Name, reg.Images, reg.ByDigest, reg.ByTag, reg.Unpinned)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Shared) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SHARED IMAGE\tAPPLICATIONS")
+	for _, v := range r.Shared {
+		fmt.Fprintf(tw, "%s\t%s\n", v.Image, strings.Join(v.Applications, ","))
+	}
+	return tw.Flush()
+}
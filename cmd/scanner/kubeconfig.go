@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// restConfig returns the configuration for the kubeconfig context, or the
+// current context if the context is empty.
+//
+// When running in a cluster without a kubeconfig, the in-cluster configuration
+// of the ServiceAccount is used for the current context.
+func restConfig(context string) (*rest.Config, error) {
+	if context == "" && os.Getenv(clientcmd.RecommendedConfigPathEnvVar) == "" {
+		cfg, err := rest.InClusterConfig()
+		if err == nil {
+			return cfg, nil
+		}
+		if !errors.Is(err, rest.ErrNotInCluster) {
+			return nil, fmt.Errorf("failed to get in-cluster configuration: %w", err)
+		}
+	}
+
+	cfg, err := config.GetConfigWithContext(context)
+	if err != nil {
+		if context == "" {
+			return nil, fmt.Errorf("failed to get configuration, not running in a cluster and no kubeconfig was found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get configuration for context %q: %w", context, err)
+	}
+	return cfg, nil
+}
+
+// contextName returns the name of the kubeconfig context, or the name of the
+// current context if the context is empty.
+//
+// The name is empty if there is no kubeconfig, for example in a cluster.
+func contextName(context string) string {
+	if context != "" {
+		return context
+	}
+	cfg, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+	if err != nil {
+		return ""
+	}
+	return cfg.CurrentContext
+}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	appsv1alpha1 "github.com/gitops-tools/apps-scanner/api/v1alpha1"
+	"github.com/gitops-tools/apps-scanner/pkg/source"
 )
 
 var (
@@ -17,18 +27,86 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(kustomizev1.AddToScheme(scheme))
+	utilruntime.Must(sourcev1.AddToScheme(scheme))
+	utilruntime.Must(appsv1alpha1.AddToScheme(scheme))
+}
+
+// sourcesFunc creates the sources of objects for commands that scan.
+//
+// Not all commands need a cluster, so the sources are only created when a
+// command needs them.
+type sourcesFunc func() ([]source.Source, error)
+
+// newSources creates a source from the configured manifest files, or a source
+// for each of the configured contexts, or for the current context if none are
+// configured.
+func newSources() ([]source.Source, error) {
+	if paths := viper.GetStringSlice("from"); len(paths) > 0 {
+		src, err := source.NewManifests(paths...)
+		if err != nil {
+			return nil, err
+		}
+		return []source.Source{src}, nil
+	}
+
+	contexts := viper.GetStringSlice("contexts")
+	if len(contexts) == 0 {
+		contexts = []string{""}
+	}
+
+	var res []source.Source
+	for _, context := range contexts {
+		cfg, err := restConfig(context)
+		if err != nil {
+			return nil, err
+		}
+		cl, err := client.NewWithWatch(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, source.NewCluster(cl,
+			source.WithName(contextName(context)),
+			source.WithPageSize(viper.GetInt64("page-size"))))
+	}
+
+	return res, nil
 }
 
 func main() {
-	cfg, err := config.GetConfig()
-	cobra.CheckErr(err)
+	rootCmd := makeRootCmd()
+	rootCmd.AddCommand(newApplicationsCmd(newSources))
+	rootCmd.AddCommand(newPipelinesCmd(newSources))
+	rootCmd.AddCommand(newReportCmd(newSources))
+	rootCmd.AddCommand(newDiffCmd(newSources))
+	rootCmd.AddCommand(newHistoryCmd(newSources))
+	rootCmd.AddCommand(newDriftCmd(newSources))
+	rootCmd.AddCommand(newExportCmd(newSources))
+	rootCmd.AddCommand(newControllerCmd())
+	rootCmd.AddCommand(newRBACCmd())
+	rootCmd.AddCommand(newEnvironmentsCmd(newSources))
+	rootCmd.AddCommand(newVersionsCmd(newSources))
+	rootCmd.AddCommand(newImagesCmd(newSources))
+	rootCmd.AddCommand(newCheckCmd(newSources))
 
-	cl, err := client.New(cfg, client.Options{Scheme: scheme})
+	// Interrupting a scan cancels the lists that are in progress.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	var exit *exitError
+	if errors.As(err, &exit) {
+		fmt.Fprintln(os.Stderr, "Error:", exit.err)
+		os.Exit(exit.code)
+	}
 	cobra.CheckErr(err)
+}
 
-	rootCmd := makeRootCmd()
-	rootCmd.AddCommand(newApplicationsCmd(cl))
-	rootCmd.AddCommand(newPipelinesCmd(cl))
+// exitError is an error that exits with a specific exit code, rather than
+// the exit code for all other errors.
+type exitError struct {
+	code int
+	err  error
+}
 
-	cobra.CheckErr(rootCmd.Execute())
+func (e *exitError) Error() string {
+	return e.err.Error()
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/viper"
+
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+)
+
+const (
+	textOutput = "text"
+	jsonOutput = "json"
+)
+
+var outputFormats = []string{textOutput, jsonOutput}
+
+// jsonOutputRequested returns true if the output should be written as JSON
+// rather than text.
+func jsonOutputRequested() (bool, error) {
+	switch format := viper.GetString("output"); format {
+	case textOutput:
+		return false, nil
+	case jsonOutput:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown output format %q, must be one of %v", format, outputFormats)
+	}
+}
+
+// progressWriter returns the writer for progress messages, which are written
+// to stderr when stdout is used for JSON output.
+func progressWriter() io.Writer {
+	if viper.GetString("output") == jsonOutput {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// warnPartial writes a warning to stderr for each of the lists that failed in
+// an incomplete scan so that the partial results can be output, any other
+// error is returned.
+func warnPartial(err error) error {
+	var partial *scanner.PartialError
+	if !errors.As(err, &partial) {
+		return err
+	}
+	for _, v := range partial.Failures {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", v)
+	}
+	return nil
+}
+
+// scanFailures returns the lists that failed if the error is from an
+// incomplete scan.
+func scanFailures(err error) []scanner.Failure {
+	var partial *scanner.PartialError
+	if errors.As(err, &partial) {
+		return partial.Failures
+	}
+	return nil
+}
+
+// writeCoverage writes the lists that could not be scanned, nothing is written
+// for a complete scan.
+//
+// The JSON output has the coverage in its coverage field.
+func writeCoverage(w io.Writer, c *inventory.Coverage) {
+	if c == nil {
+		return
+	}
+	fmt.Fprintln(w, "coverage: incomplete")
+	if len(c.Skipped) > 0 {
+		fmt.Fprintln(w, "  skipped, listing is not permitted:")
+		for _, v := range c.Skipped {
+			if v.Namespace == "" {
+				fmt.Fprintf(w, "    %s: %s\n", v, v.Reason)
+				continue
+			}
+			fmt.Fprintf(w, "    %s\n", v)
+		}
+	}
+	if len(c.Failed) > 0 {
+		fmt.Fprintln(w, "  failed:")
+		for _, v := range c.Failed {
+			fmt.Fprintf(w, "    %s: %s\n", v, v.Reason)
+		}
+	}
+}
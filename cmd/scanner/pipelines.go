@@ -1,43 +1,134 @@
 package main
 
 import (
-	"context"
 	"fmt"
+	"os"
 	"strings"
 
-	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
 	"github.com/spf13/cobra"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/spf13/viper"
 
-	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/promotion"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+	"github.com/gitops-tools/apps-scanner/pkg/visualise"
 )
 
-func newPipelinesCmd(cl client.Client) *cobra.Command {
-	return &cobra.Command{
+func newPipelinesCmd(newSources sourcesFunc) *cobra.Command {
+	cmd := &cobra.Command{
 		Use:   "pipelines",
 		Short: "List pipelines in the cluster",
-		RunE:  listPipelines(cl),
+		RunE:  listPipelines(newSources),
 	}
+
+	addDiagramFlags(cmd, "pipelines")
+	cmd.AddCommand(newPipelinesExportCmd(newSources))
+	cmd.AddCommand(newPipelinesSourcesCmd(newSources))
+
+	return cmd
+}
+
+func newPipelinesExportCmd(newSources sourcesFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the pipelines as manifests for a promotion tool",
+		Long: `Export the discovered pipelines as Weave GitOps Pipelines, or as Kargo
+Projects, Warehouses and Stages, generated from the Kustomizations and
+GitRepositories that deliver each stage.`,
+		Args: cobra.NoArgs,
+		RunE: exportPipelines(newSources),
+	}
+
+	cmd.Flags().String("format", promotion.WeaveGitOpsFormat, fmt.Sprintf("Format of the exported manifests, one of %s", strings.Join(promotion.Formats, ", ")))
+	cobra.CheckErr(viper.BindPFlag("pipelines.export.format", cmd.Flags().Lookup("format")))
+	cmd.Flags().String("file", "", "Write the manifests to this file rather than stdout")
+	cobra.CheckErr(viper.BindPFlag("pipelines.export.file", cmd.Flags().Lookup("file")))
+
+	return cmd
 }
 
-func listPipelines(cl client.Client) func(*cobra.Command, []string) error {
+func exportPipelines(newSources sourcesFunc) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		fmt.Println("Starting to scan for kustomizations")
-		kustomizationList := &kustomizev1.KustomizationList{}
-		err := cl.List(context.Background(), kustomizationList, client.HasLabels([]string{pipelines.PipelineNameLabel}))
+		// Keep stdout for the exported manifests.
+		s, err := newScanner(newSources, scanner.WithProgress(os.Stderr))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+		kustomizations, err := s.PipelineKustomizations(ctx)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+		discovered, err := s.PipelinesFromKustomizations(kustomizations)
+		if err != nil {
+			return err
+		}
+		repositories, err := s.GitRepositories(ctx)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+
+		var pls []promotion.Pipeline
+		for _, pl := range discovered {
+			pls = append(pls, promotion.Pipeline{
+				Name:   pl.Name,
+				Stages: s.PipelineStages(pl, kustomizations),
+			})
+		}
+		exported, err := promotion.Export(viper.GetString("pipelines.export.format"), pls, repositories)
 		if err != nil {
-			return fmt.Errorf("failed to list kustomizations: %w", err)
+			return err
 		}
-		fmt.Printf("found %d kustomizations\n", len(kustomizationList.Items))
 
-		p := pipelines.NewParser()
-		pipelines, err := p.Pipelines()
+		if filename := viper.GetString("pipelines.export.file"); filename != "" {
+			return os.WriteFile(filename, []byte(exported), 0644)
+		}
+		_, err = fmt.Fprint(os.Stdout, exported)
+		return err
+	}
+}
+
+func listPipelines(newSources sourcesFunc) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		asJSON, err := jsonOutputRequested()
 		if err != nil {
-			return fmt.Errorf("failed to discover pipelines: %w", err)
+			return err
+		}
+		s, err := newScanner(newSources)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+
+		fmt.Fprintln(progressWriter(), "Starting to scan for kustomizations")
+		discovered, err := s.Pipelines(ctx)
+		failures := scanFailures(err)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+
+		if asJSON {
+			if err := inventory.WriteResult(os.Stdout, "pipelines", discovered, scanner.NewCoverage(failures)); err != nil {
+				return err
+			}
+		} else {
+			for _, v := range discovered {
+				fmt.Printf("pipeline %s has stages: %s\n", v.Name, strings.Join(v.Environments, ","))
+			}
+			writeCoverage(os.Stdout, scanner.NewCoverage(failures))
 		}
 
-		for _, v := range pipelines {
-			fmt.Printf("pipeline %s has stages: %s\n", v.Name, strings.Join(v.Environments, ","))
+		if filename := viper.GetString("pipelines.diagram-file"); filename != "" {
+			render := func(format string) (string, error) {
+				return visualise.RenderPipelines(format, discovered)
+			}
+			if err := writeDiagram(filename, viper.GetString("pipelines.diagram-format"), render); err != nil {
+				return err
+			}
 		}
 		return nil
 	}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gitops-tools/apps-scanner/pkg/rbac"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+)
+
+func newRBACCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rbac",
+		Short: "Generate the RBAC resources that scanning needs",
+		Long: `Generate a ClusterRole and ClusterRoleBinding, or a Role and RoleBinding in
+each of the configured namespaces, that grant the list and watch verbs on the
+configured kinds and the Flux and Argo CD kinds.
+
+Access is reviewed and discovery is made with the permissions that every
+authenticated user is granted by default.`,
+		Args: cobra.NoArgs,
+		RunE: generateRBAC,
+	}
+
+	cmd.Flags().String("name", "apps-scanner", "Name of the generated roles and bindings")
+	cobra.CheckErr(viper.BindPFlag("rbac.name", cmd.Flags().Lookup("name")))
+	cmd.Flags().String("service-account", "apps-scanner", "Name of the ServiceAccount the scanner runs as")
+	cobra.CheckErr(viper.BindPFlag("rbac.service-account", cmd.Flags().Lookup("service-account")))
+	cmd.Flags().String("service-account-namespace", "apps-scanner", "Namespace of the ServiceAccount the scanner runs as")
+	cobra.CheckErr(viper.BindPFlag("rbac.service-account-namespace", cmd.Flags().Lookup("service-account-namespace")))
+
+	return cmd
+}
+
+func generateRBAC(cmd *cobra.Command, args []string) error {
+	kinds := scanner.New(scannerOptions()...).ListedKinds()
+
+	return rbac.Write(os.Stdout, rbac.Resources(kinds, rbac.Options{
+		Name: viper.GetString("rbac.name"),
+		ServiceAccount: types.NamespacedName{
+			Name:      viper.GetString("rbac.service-account"),
+			Namespace: viper.GetString("rbac.service-account-namespace"),
+		},
+		Namespaces: viper.GetStringSlice("namespaces"),
+	}))
+}
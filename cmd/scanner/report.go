@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/report"
+)
+
+func newReportCmd(newSources sourcesFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Generate a report of the applications, pipelines and repositories in the cluster",
+		RunE:  generateReport(newSources),
+	}
+
+	cmd.Flags().String("html", "", "Write a self-contained HTML report to this file")
+	cobra.CheckErr(viper.BindPFlag("report.html", cmd.Flags().Lookup("html")))
+
+	cmd.Flags().String("json", "", "Write the scanned inventory as JSON to this file")
+	cobra.CheckErr(viper.BindPFlag("report.json", cmd.Flags().Lookup("json")))
+
+	return cmd
+}
+
+func generateReport(newSources sourcesFunc) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		htmlFilename := viper.GetString("report.html")
+		jsonFilename := viper.GetString("report.json")
+		if htmlFilename == "" && jsonFilename == "" {
+			return fmt.Errorf("no report file provided, use --html or --json")
+		}
+
+		s, err := newScanner(newSources)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Starting to scan for the inventory")
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+
+		inv, err := s.Inventory(ctx)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+
+		if htmlFilename != "" {
+			if err := writeReport(htmlFilename, func(f *os.File) error {
+				return report.WriteHTML(f, *inv, time.Now())
+			}); err != nil {
+				return err
+			}
+		}
+
+		if jsonFilename != "" {
+			if err := writeReport(jsonFilename, func(f *os.File) error {
+				return inventory.Write(f, *inv)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func writeReport(filename string, write func(*os.File) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	defer f.Close()
+
+	if err := write(f); err != nil {
+		return err
+	}
+	fmt.Printf("report written to %s\n", filename)
+	return f.Close()
+}
@@ -1,12 +1,63 @@
 package main
 
-import "github.com/spf13/cobra"
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+	"github.com/gitops-tools/apps-scanner/pkg/source"
+)
 
 func makeRootCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:           "scanner <command>",
 		Short:         "Scan repositories",
 		Long:          "Scan and log information from clusters based on labels",
 		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return initConfig(viper.GetString("config"))
+		},
+	}
+
+	cmd.PersistentFlags().String("config", "", "Configuration file, defaults to "+configName+".yaml in the current, XDG config or home directory")
+	cobra.CheckErr(viper.BindPFlag("config", cmd.PersistentFlags().Lookup("config")))
+
+	cmd.PersistentFlags().StringSlice("kinds", []string{"Deployment.apps"}, "Kinds of resource to scan for applications, e.g. Deployment.apps,StatefulSet.apps")
+	cobra.CheckErr(viper.BindPFlag("kinds", cmd.PersistentFlags().Lookup("kinds")))
+
+	cmd.PersistentFlags().StringSlice("namespaces", nil, "Namespaces to scan, defaults to all namespaces")
+	cobra.CheckErr(viper.BindPFlag("namespaces", cmd.PersistentFlags().Lookup("namespaces")))
+
+	cmd.PersistentFlags().StringSlice("contexts", nil, "Kubeconfig contexts to scan, defaults to the current context")
+	cobra.CheckErr(viper.BindPFlag("contexts", cmd.PersistentFlags().Lookup("contexts")))
+
+	cmd.PersistentFlags().StringSlice("from", nil, "Manifest files or directories to scan instead of clusters, including the output of kubectl get -o yaml")
+	cobra.CheckErr(viper.BindPFlag("from", cmd.PersistentFlags().Lookup("from")))
+
+	cmd.PersistentFlags().Int64("page-size", source.DefaultPageSize, "Number of resources to request in each page when listing, 0 disables paging")
+	cobra.CheckErr(viper.BindPFlag("page-size", cmd.PersistentFlags().Lookup("page-size")))
+
+	cmd.PersistentFlags().Int("concurrency", scanner.DefaultConcurrency, "Number of lists to make concurrently across kinds, namespaces and contexts")
+	cobra.CheckErr(viper.BindPFlag("concurrency", cmd.PersistentFlags().Lookup("concurrency")))
+
+	cmd.PersistentFlags().Duration("timeout", 0, "Maximum time to scan for, lists that have not completed are reported as failed, defaults to no timeout")
+	cobra.CheckErr(viper.BindPFlag("timeout", cmd.PersistentFlags().Lookup("timeout")))
+
+	cmd.PersistentFlags().StringP("output", "o", textOutput, fmt.Sprintf("Output format, one of %v", outputFormats))
+	cobra.CheckErr(viper.BindPFlag("output", cmd.PersistentFlags().Lookup("output")))
+
+	return cmd
+}
+
+// commandContext returns a context for the command that is cancelled when the
+// command is interrupted, or the configured timeout expires.
+func commandContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if timeout := viper.GetDuration("timeout"); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
 	}
+	return context.WithCancel(ctx)
 }
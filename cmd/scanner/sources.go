@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+)
+
+// pipelineSources is a Pipeline with the sources of its stages, and the
+// promotion conventions that the stages violate.
+type pipelineSources struct {
+	Name       string                          `json:"name"`
+	Sources    []pipelines.StageSource         `json:"sources"`
+	Violations []pipelines.ConventionViolation `json:"violations,omitempty"`
+}
+
+func newPipelinesSourcesCmd(newSources sourcesFunc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "sources",
+		Short: "List the source of each stage of the pipelines",
+		Long: `List the GitRepository ref and path that each Kustomization in each stage of
+the pipelines tracks, and the stages that don't follow the promotion
+conventions.
+
+A stage shouldn't track a branch after an earlier stage tracks a tag, semver
+range or commit, and two stages shouldn't track the same ref and path.`,
+		Args: cobra.NoArgs,
+		RunE: listPipelineSources(newSources),
+	}
+}
+
+func listPipelineSources(newSources sourcesFunc) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		asJSON, err := jsonOutputRequested()
+		if err != nil {
+			return err
+		}
+		s, err := newScanner(newSources)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+
+		fmt.Fprintln(progressWriter(), "Starting to scan for pipelines")
+		kustomizations, err := s.PipelineKustomizations(ctx)
+		failures := scanFailures(err)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+		discovered, err := s.PipelinesFromKustomizations(kustomizations)
+		if err != nil {
+			return err
+		}
+		repositories, err := s.GitRepositories(ctx)
+		failures = append(failures, scanFailures(err)...)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+
+		res := []pipelineSources{}
+		for _, pl := range discovered {
+			sources := pipelines.StageSources(s.PipelineStages(pl, kustomizations), repositories)
+			res = append(res, pipelineSources{
+				Name:       pl.Name,
+				Sources:    sources,
+				Violations: pipelines.CheckConventions(pl, sources),
+			})
+		}
+		if asJSON {
+			return inventory.WriteResult(os.Stdout, "pipelines", res, scanner.NewCoverage(failures))
+		}
+		if err := writePipelineSources(os.Stdout, res); err != nil {
+			return err
+		}
+		writeCoverage(os.Stdout, scanner.NewCoverage(failures))
+		return nil
+	}
+}
+
+// writePipelineSources writes a table of the sources of the stages of the
+// pipelines, followed by the violations of the promotion conventions.
+func writePipelineSources(w io.Writer, pls []pipelineSources) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PIPELINE\tSTAGE\tKUSTOMIZATION\tURL\tREF\tPATH")
+	for _, pl := range pls {
+		for _, src := range pl.Sources {
+			url, ref := "-", "-"
+			if src.Ref != nil {
+				url, ref = src.URL, flux.DescribeRef(*src.Ref)
+				if ref == "" {
+					ref = "default branch"
+				}
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", pl.Name, src.Stage, src.Kustomization, url, ref, src.Path)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, pl := range pls {
+		for _, v := range pl.Violations {
+			fmt.Fprintf(w, "%s: %s (%s)\n", pl.Name, v.Message, v.Convention)
+		}
+	}
+	return nil
+}
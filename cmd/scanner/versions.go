@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/scanner"
+)
+
+func newVersionsCmd(newSources sourcesFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "versions <application>",
+		Short: "Show the versions of an application in each environment",
+		Long: `Show the versions of the components of an application, and the applications
+that are part of it, in each of the environments.
+
+The version of each workload is taken from the app.kubernetes.io/version
+label, the tag or digest of the image of its first container, or the version
+of the Helm chart in the helm.sh/chart label.
+
+If no environments are configured, the versions are shown for each of the
+instances of the application.`,
+		Args: cobra.ExactArgs(1),
+		RunE: showVersions(newSources),
+	}
+
+	cmd.Flags().StringSlice("order", nil, "Order of the environments, defaults to the environments.order configuration")
+	cobra.CheckErr(viper.BindPFlag("versions.order", cmd.Flags().Lookup("order")))
+
+	return cmd
+}
+
+func showVersions(newSources sourcesFunc) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		asJSON, err := jsonOutputRequested()
+		if err != nil {
+			return err
+		}
+		order := viper.GetStringSlice("versions.order")
+		if len(order) == 0 {
+			order = viper.GetStringSlice("environments.order")
+		}
+		s, err := newScanner(newSources, scanner.WithVersions())
+		if err != nil {
+			return err
+		}
+		ctx, cancel := commandContext(cmd)
+		defer cancel()
+
+		fmt.Fprintln(progressWriter(), "Starting to scan for applications")
+		apps, err := s.Applications(ctx)
+		failures := scanFailures(err)
+		if err := warnPartial(err); err != nil {
+			return err
+		}
+
+		name := args[0]
+		var selected []applications.Application
+		for _, app := range apps {
+			if app.Name == name || hasParentApplication(app, name) {
+				selected = append(selected, app)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("application %q not found", name)
+		}
+
+		matrix := applications.NewVersionMatrix(selected, order...)
+		if asJSON {
+			return inventory.WriteResult(os.Stdout, "versions", matrix, scanner.NewCoverage(failures))
+		}
+		if err := writeVersionMatrix(os.Stdout, matrix); err != nil {
+			return err
+		}
+		writeCoverage(os.Stdout, scanner.NewCoverage(failures))
+		return nil
+	}
+}
+
+// writeVersionMatrix writes a table of the components of applications with
+// their versions in each of the environments or instances.
+func writeVersionMatrix(w io.Writer, m applications.VersionMatrix) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "APPLICATION\tCOMPONENT\t%s\n", strings.Join(m.Columns, "\t"))
+	for _, row := range m.Rows {
+		cells := make([]string, len(row.Cells))
+		for i, v := range row.Cells {
+			cells[i] = "-"
+			if len(v) > 0 {
+				cells[i] = strings.Join(v, ",")
+			}
+		}
+		component := row.Component
+		if component == "" {
+			component = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", row.Application, component, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
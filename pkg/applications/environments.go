@@ -0,0 +1,121 @@
+package applications
+
+import (
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Environment is an environment that an Application is deployed to, with the
+// instances and components that are deployed there.
+type Environment struct {
+	Name       string   `json:"name"`
+	Instances  []string `json:"instances,omitempty"`
+	Components []string `json:"components,omitempty"`
+	// Versions are only detected when the Parser is configured with
+	// versions.
+	Versions []string `json:"versions,omitempty"`
+}
+
+// Environments configures how the environment of each resource is identified,
+// the first of the configured sources that identifies an environment is used,
+// in the order of the fields.
+//
+// Resources without an identified environment are not in any Environment.
+type Environments struct {
+	// Label is a label on resources with the name of their environment.
+	Label string
+
+	// Pipelines identifies the environment from the pipeline environment
+	// label of the Kustomization that delivers the resources.
+	Pipelines bool
+
+	// Kustomizations are the environments of the Kustomizations in pipelines.
+	Kustomizations map[types.NamespacedName]string
+
+	// Namespace matches namespaces that follow a naming convention, the first
+	// submatch is the environment, or the whole namespace if there are no
+	// submatches e.g. ^sockshop-(.+)$.
+	Namespace *regexp.Regexp
+
+	// Cluster identifies the environment as the name of the cluster the
+	// resources are discovered in.
+	Cluster bool
+}
+
+// identify returns the environment of a resource, or an empty string if no
+// environment is identified.
+func (e Environments) identify(cluster, namespace string, labels map[string]string) string {
+	if e.Label != "" {
+		if env := labels[e.Label]; env != "" {
+			return env
+		}
+	}
+	if e.Pipelines {
+		if nn := kustomizationRefFromLabels(labels); nn != nil {
+			if env := e.Kustomizations[*nn]; env != "" {
+				return env
+			}
+		}
+	}
+	if e.Namespace != nil && namespace != "" {
+		if m := e.Namespace.FindStringSubmatch(namespace); m != nil {
+			if len(m) > 1 {
+				return m[1]
+			}
+			return m[0]
+		}
+	}
+	if e.Cluster {
+		return cluster
+	}
+	return ""
+}
+
+// Matrix is the Applications that are deployed to each of the Environments.
+type Matrix struct {
+	Environments []string    `json:"environments"`
+	Rows         []MatrixRow `json:"rows"`
+}
+
+// MatrixRow is an Application and where it is deployed.
+//
+// There is a cell for each of the Environments of the Matrix, in the same
+// order, which is nil if the Application is not deployed to the Environment.
+type MatrixRow struct {
+	Application string         `json:"application"`
+	Cells       []*Environment `json:"cells"`
+}
+
+// NewMatrix returns the Matrix of the Applications that are deployed to
+// environments.
+//
+// The environments are in the order provided, followed by any others in
+// alphabetical order.
+func NewMatrix(apps []Application, order ...string) Matrix {
+	known := map[string]bool{}
+	for _, app := range apps {
+		for _, env := range app.Environments {
+			known[env.Name] = true
+		}
+	}
+
+	envs := orderNames(known, order)
+
+	m := Matrix{Environments: envs, Rows: []MatrixRow{}}
+	for _, app := range apps {
+		if len(app.Environments) == 0 {
+			continue
+		}
+		row := MatrixRow{Application: app.Name, Cells: make([]*Environment, len(envs))}
+		for i := range app.Environments {
+			for j, env := range envs {
+				if app.Environments[i].Name == env {
+					row.Cells[j] = &app.Environments[i]
+				}
+			}
+		}
+		m.Rows = append(m.Rows, row)
+	}
+	return m
+}
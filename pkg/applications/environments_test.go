@@ -0,0 +1,144 @@
+package applications
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestParser_environments(t *testing.T) {
+	dev := makePod(inNamespace("sockshop-dev"), withLabels(map[string]string{
+		nameLabel:              "cart",
+		componentLabel:         "backend",
+		instanceLabel:          "cart-dev",
+		"example.com/env":      "development",
+		kustomizationName:      "cart-dev",
+		kustomizationNamespace: "flux-system",
+	}))
+	prod := makePod(inNamespace("sockshop-prod"), withLabels(map[string]string{
+		nameLabel:      "cart",
+		componentLabel: "backend",
+		instanceLabel:  "cart-prod",
+	}))
+
+	envTests := []struct {
+		name string
+		opts []func(*Parser)
+		want []Environment
+	}{
+		{
+			name: "not configured",
+		},
+		{
+			name: "from a label",
+			opts: []func(*Parser){WithEnvironments(Environments{Label: "example.com/env"})},
+			want: []Environment{
+				{Name: "development", Instances: []string{"cart-dev"}, Components: []string{"backend"}},
+			},
+		},
+		{
+			name: "from pipelines",
+			opts: []func(*Parser){WithEnvironments(Environments{
+				Pipelines:      true,
+				Kustomizations: map[types.NamespacedName]string{{Name: "cart-dev", Namespace: "flux-system"}: "staging"},
+			})},
+			want: []Environment{
+				{Name: "staging", Instances: []string{"cart-dev"}, Components: []string{"backend"}},
+			},
+		},
+		{
+			name: "from a namespace convention",
+			opts: []func(*Parser){WithEnvironments(Environments{Namespace: regexp.MustCompile(`^sockshop-(.+)$`)})},
+			want: []Environment{
+				{Name: "dev", Instances: []string{"cart-dev"}, Components: []string{"backend"}},
+				{Name: "prod", Instances: []string{"cart-prod"}, Components: []string{"backend"}},
+			},
+		},
+		{
+			name: "from a namespace convention without a submatch",
+			opts: []func(*Parser){WithEnvironments(Environments{Namespace: regexp.MustCompile(`[a-z]+$`)})},
+			want: []Environment{
+				{Name: "dev", Instances: []string{"cart-dev"}, Components: []string{"backend"}},
+				{Name: "prod", Instances: []string{"cart-prod"}, Components: []string{"backend"}},
+			},
+		},
+		{
+			name: "from the cluster",
+			opts: []func(*Parser){WithEnvironments(Environments{Cluster: true})},
+			want: []Environment{
+				{Name: "test-cluster", Instances: []string{"cart-dev", "cart-prod"}, Components: []string{"backend"}},
+			},
+		},
+		{
+			name: "first identified environment",
+			opts: []func(*Parser){WithEnvironments(Environments{Label: "example.com/env", Cluster: true})},
+			want: []Environment{
+				{Name: "development", Instances: []string{"cart-dev"}, Components: []string{"backend"}},
+				{Name: "test-cluster", Instances: []string{"cart-prod"}, Components: []string{"backend"}},
+			},
+		},
+		{
+			name: "no environment identified",
+			opts: []func(*Parser){WithEnvironments(Environments{Label: "example.com/missing"})},
+			want: []Environment{},
+		},
+	}
+
+	for _, tt := range envTests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.opts...)
+			p.Cluster = "test-cluster"
+			if err := p.Add([]runtime.Object{dev, prod}); err != nil {
+				t.Fatal(err)
+			}
+
+			apps := p.Applications()
+			if diff := cmp.Diff(tt.want, apps[0].Environments); diff != "" {
+				t.Fatalf("failed to identify environments:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewMatrix(t *testing.T) {
+	apps := []Application{
+		{
+			Name: "cart",
+			Environments: []Environment{
+				{Name: "dev", Components: []string{"backend"}},
+				{Name: "prod", Components: []string{"backend"}},
+			},
+		},
+		{
+			Name: "orders",
+			Environments: []Environment{
+				{Name: "qa", Components: []string{"api"}},
+				{Name: "prod", Components: []string{"api"}},
+			},
+		},
+		{Name: "sockshop"},
+	}
+
+	m := NewMatrix(apps, "dev", "staging", "prod")
+
+	want := Matrix{
+		Environments: []string{"dev", "prod", "qa"},
+		Rows: []MatrixRow{
+			{Application: "cart", Cells: []*Environment{&apps[0].Environments[0], &apps[0].Environments[1], nil}},
+			{Application: "orders", Cells: []*Environment{nil, &apps[1].Environments[1], &apps[1].Environments[0]}},
+		},
+	}
+	if diff := cmp.Diff(want, m); diff != "" {
+		t.Fatalf("failed to create matrix:\n%s", diff)
+	}
+}
+
+func inNamespace(ns string) func(runtime.Object) {
+	return func(obj runtime.Object) {
+		obj.(*corev1.Pod).Namespace = ns
+	}
+}
@@ -3,9 +3,14 @@ package applications
 import (
 	"fmt"
 	"sort"
+	"strings"
 
+	"github.com/gitops-tools/apps-scanner/pkg/workloads"
 	"github.com/gitops-tools/pkg/sets"
+	"golang.org/x/exp/maps"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -22,30 +27,104 @@ const (
 
 	kustomizationName      = "kustomize.toolkit.fluxcd.io/name"
 	kustomizationNamespace = "kustomize.toolkit.fluxcd.io/namespace"
+
+	// ArgoCDTrackingIDAnnotation is the annotation that Argo CD adds to
+	// resources when tracking by annotation.
+	ArgoCDTrackingIDAnnotation = "argocd.argoproj.io/tracking-id"
 )
 
 // Application represents a discovered deployment group.
 type Application struct {
-	Name           string
-	Instances      []string
-	Components     []string
-	Parents        []Application
-	Kustomizations []types.NamespacedName
+	Name           string                 `json:"name"`
+	Instances      []string               `json:"instances,omitempty"`
+	Components     []string               `json:"components,omitempty"`
+	Parents        []Application          `json:"parents,omitempty"`
+	Kustomizations []types.NamespacedName `json:"kustomizations,omitempty"`
+
+	// ArgoApplications are the Argo CD Applications that track the resources
+	// by annotation, the Namespace is empty unless the Application is outside
+	// of the Argo CD namespace.
+	ArgoApplications []types.NamespacedName `json:"argoApplications,omitempty"`
+
+	// Environments are only identified when the Parser is configured with
+	// Environments.
+	Environments []Environment `json:"environments,omitempty"`
+
+	// Versions are only detected when the Parser is configured with versions.
+	Versions []Version `json:"versions,omitempty"`
 }
 
 // Parser parses the labels and annotations on runtime Objects and extracts apps
 // from the labels.
 type Parser struct {
 	Accessor meta.MetadataAccessor
-	apps     map[string]discoveryApplication
+	Labels   Labels
+
+	// Cluster is the name of the cluster that the added objects are
+	// discovered in, it can be changed between adding objects from different
+	// clusters.
+	Cluster string
+
+	environments *Environments
+	versions     bool
+	apps         map[string]discoveryApplication
+}
+
+// Labels configures the set of labels to examine resources for.
+type Labels struct {
+	Name      string
+	PartOf    string
+	Instance  string
+	Component string
+}
+
+// WithLabels is a functional option for configuring the Parser with a set of
+// labels.
+func WithLabels(name, partOf, instance, component string) func(*Parser) {
+	return func(p *Parser) {
+		p.Labels.Name = name
+		p.Labels.PartOf = partOf
+		p.Labels.Instance = instance
+		p.Labels.Component = component
+	}
+}
+
+// WithEnvironments is a functional option for configuring the Parser to
+// identify the environments of the instances of Applications.
+func WithEnvironments(e Environments) func(*Parser) {
+	return func(p *Parser) {
+		p.environments = &e
+	}
+}
+
+// WithVersions is a functional option for configuring the Parser to detect
+// the versions of the components of Applications.
+//
+// Versions are detected from the images of workloads that are added as
+// complete objects, objects added with AddMetadata only have the versions from
+// their labels.
+func WithVersions() func(*Parser) {
+	return func(p *Parser) {
+		p.versions = true
+	}
 }
 
 // NewParser creates and returns a new Parser ready for use.
-func NewParser() *Parser {
-	return &Parser{
+func NewParser(opts ...func(*Parser)) *Parser {
+	p := &Parser{
 		Accessor: meta.NewAccessor(),
 		apps:     make(map[string]discoveryApplication),
+		Labels: Labels{
+			Name:      nameLabel,
+			PartOf:    partOfLabel,
+			Instance:  instanceLabel,
+			Component: componentLabel,
+		},
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
+	return p
 }
 
 // Add a set of runtime Objects to the parser.
@@ -58,77 +137,208 @@ func (p *Parser) Add(list []runtime.Object) error {
 		if err != nil {
 			return fmt.Errorf("failed to get labels from %v: %w", obj, err)
 		}
-		appName := l[nameLabel]
-		if appName == "" {
-			continue
+		annotations, err := p.Accessor.Annotations(obj)
+		if err != nil {
+			return fmt.Errorf("failed to get annotations from %v: %w", obj, err)
 		}
-		a, ok := p.apps[appName]
-		if !ok {
-			a = discoveryApplication{
-				name:           appName,
-				instances:      sets.New[string](),
-				parents:        sets.New[string](),
-				components:     sets.New[string](),
-				kustomizations: sets.New[types.NamespacedName](),
+		ns, err := p.Accessor.Namespace(obj)
+		if err != nil {
+			return fmt.Errorf("failed to get namespace from %v: %w", obj, err)
+		}
+		var images []string
+		if p.versions {
+			u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+			if err != nil {
+				return fmt.Errorf("failed to convert %v: %w", obj, err)
 			}
+			images = workloads.Images(unstructured.Unstructured{Object: u})
+		}
+		p.add(ns, l, annotations, images)
+	}
+	return nil
+}
+
+// AddMetadata adds the metadata of a set of objects to the parser.
+//
+// This avoids decoding complete objects when only the metadata is listed,
+// which is all that is needed to discover Applications.
+func (p *Parser) AddMetadata(list []metav1.PartialObjectMetadata) {
+	for i := range list {
+		p.add(list[i].GetNamespace(), list[i].GetLabels(), list[i].GetAnnotations(), nil)
+	}
+}
+
+// AddObjects adds a set of objects to the parser, for example the typed
+// objects from a list.
+func (p *Parser) AddObjects(list []metav1.Object) {
+	for _, obj := range list {
+		p.add(obj.GetNamespace(), obj.GetLabels(), obj.GetAnnotations(), nil)
+	}
+}
+
+// AddUnstructured adds a set of unstructured objects to the parser.
+//
+// This doesn't reduce the memory used compared with Add, decoding unstructured
+// objects allocates more than decoding typed objects, use AddMetadata with
+// lists of metadata to reduce the memory used.
+func (p *Parser) AddUnstructured(list []unstructured.Unstructured) {
+	for i := range list {
+		var images []string
+		if p.versions {
+			images = workloads.Images(list[i])
+		}
+		p.add(list[i].GetNamespace(), list[i].GetLabels(), list[i].GetAnnotations(), images)
+	}
+}
+
+// add records the Application from the namespace, labels, annotations and
+// container images of an object.
+func (p *Parser) add(ns string, l, annotations map[string]string, images []string) {
+	appName := l[p.Labels.Name]
+	if appName == "" {
+		return
+	}
+	a, ok := p.apps[appName]
+	if !ok {
+		a = discoveryApplication{
+			name:           appName,
+			instances:      sets.New[string](),
+			parents:        sets.New[string](),
+			components:     sets.New[string](),
+			kustomizations: sets.New[types.NamespacedName](),
+			argoApps:       sets.New[types.NamespacedName](),
 		}
-		// TODO: this should check for the presence of these labels!
-		a.instances.Insert(l[instanceLabel])
-		a.components.Insert(l[componentLabel])
-		if v := l[partOfLabel]; v != "" {
-			a.parents.Insert(l[partOfLabel])
+		if p.environments != nil {
+			a.environments = map[string]*discoveryEnvironment{}
 		}
-		if nn := kustomizationRefFromLabels(l); nn != nil {
-			a.kustomizations.Insert(*nn)
+		if p.versions {
+			a.versions = sets.New[Version]()
 		}
-		p.apps[appName] = a
 	}
-	return nil
+	// TODO: this should check for the presence of these labels!
+	a.instances.Insert(l[p.Labels.Instance])
+	a.components.Insert(l[p.Labels.Component])
+	if v := l[p.Labels.PartOf]; v != "" {
+		a.parents.Insert(v)
+	}
+	if nn := kustomizationRefFromLabels(l); nn != nil {
+		a.kustomizations.Insert(*nn)
+	}
+	if nn := argoApplicationRefFromAnnotations(annotations); nn != nil {
+		a.argoApps.Insert(*nn)
+	}
+	var envName string
+	if p.environments != nil {
+		envName = p.environments.identify(p.Cluster, ns, l)
+	}
+	var version string
+	if p.versions {
+		var source string
+		version, source = versionOf(l, images)
+		if version != "" {
+			a.versions.Insert(Version{
+				Component:   l[p.Labels.Component],
+				Instance:    l[p.Labels.Instance],
+				Environment: envName,
+				Version:     version,
+				Source:      source,
+			})
+		}
+	}
+	if envName != "" {
+		env, ok := a.environments[envName]
+		if !ok {
+			env = &discoveryEnvironment{instances: sets.New[string](), components: sets.New[string](), versions: sets.New[string]()}
+			a.environments[envName] = env
+		}
+		env.instances.Insert(l[p.Labels.Instance])
+		env.components.Insert(l[p.Labels.Component])
+		if version != "" {
+			env.versions.Insert(version)
+		}
+	}
+	p.apps[appName] = a
 }
 
 // Applications returns the Applications that were discovered during the parsing
 // process.
 func (p *Parser) Applications() []Application {
-	apps := map[string]Application{}
-	for _, v := range p.apps {
-		app, ok := apps[v.name]
-		if !ok {
-			app = Application{
-				Name: v.name,
-			}
-		}
-
-		app.Instances = v.instances.List()
-		app.Components = v.components.List()
-		app.Kustomizations = v.kustomizations.List()
-
-		// Scan the parents of the app, link the child to the parent Application
-		// object.
-		//
-		// If the parent is not known at this time, add it to the list of known
-		// Applications.
-		// TODO: simplify this to a two-pass check?
-
-		for _, p := range v.parents.List() {
-			if a, ok := apps[p]; ok {
-				app.Parents = append(app.Parents, a)
-			} else {
-				newApp := Application{Name: p}
-				apps[p] = newApp
-				app.Parents = append(app.Parents, newApp)
+	// Parents that have not been discovered from their own labels are added to
+	// the list of known Applications.
+	known := map[string]discoveryApplication{}
+	for name, v := range p.apps {
+		known[name] = v
+		for _, parent := range v.parents.List() {
+			if _, ok := p.apps[parent]; !ok {
+				known[parent] = discoveryApplication{name: parent}
 			}
 		}
-		apps[app.Name] = app
 	}
 
+	// The parents are resolved once all the Applications are known so that
+	// each parent is complete, regardless of the order of discovery.
+	resolved := map[string]Application{}
 	res := []Application{}
-	for _, v := range apps {
-		res = append(res, v)
+	for name := range known {
+		res = append(res, resolveApplication(name, known, resolved, map[string]bool{}))
 	}
 	// Sorting to ensure that the tests are stable
 	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
 	return res
+}
 
+// resolveApplication converts the named discoveryApplication to an Application
+// with its parents resolved, recording the resolved Applications.
+func resolveApplication(name string, known map[string]discoveryApplication, resolved map[string]Application, seen map[string]bool) Application {
+	if app, ok := resolved[name]; ok {
+		return app
+	}
+	v := known[name]
+	app := Application{Name: name}
+	if v.instances == nil {
+		// This is a parent that was not discovered from its own labels.
+		resolved[name] = app
+		return app
+	}
+	app.Instances = sortedStrings(v.instances)
+	app.Components = sortedStrings(v.components)
+	app.Kustomizations = v.kustomizations.List()
+	app.ArgoApplications = v.argoApps.List()
+	if v.environments != nil {
+		app.Environments = []Environment{}
+		names := maps.Keys(v.environments)
+		sort.Strings(names)
+		for _, name := range names {
+			env := v.environments[name]
+			app.Environments = append(app.Environments, Environment{
+				Name:       name,
+				Instances:  sortedStrings(env.instances),
+				Components: sortedStrings(env.components),
+				Versions:   sortedStrings(env.versions),
+			})
+		}
+	}
+	if v.versions != nil {
+		app.Versions = v.versions.List()
+		sortVersions(app.Versions)
+	}
+
+	// Guard against cycles in the parent relationships.
+	seen[name] = true
+	for _, parent := range sortedStrings(v.parents) {
+		if seen[parent] {
+			app.Parents = append(app.Parents, Application{Name: parent})
+			continue
+		}
+		app.Parents = append(app.Parents, resolveApplication(parent, known, resolved, seen))
+	}
+	delete(seen, name)
+	resolved[name] = app
+	return app
+}
+
+func sortedStrings(s sets.Set[string]) []string {
+	return s.SortedList(func(x, y string) bool { return x < y })
 }
 
 // discoveryApplication is a temporary holding type to simplify identification
@@ -139,6 +349,19 @@ type discoveryApplication struct {
 	parents        sets.Set[string]
 	components     sets.Set[string]
 	kustomizations sets.Set[types.NamespacedName]
+	argoApps       sets.Set[types.NamespacedName]
+	// environments is nil unless environments are identified.
+	environments map[string]*discoveryEnvironment
+	// versions is nil unless versions are detected.
+	versions sets.Set[Version]
+}
+
+// discoveryEnvironment is the instances, components and versions of an
+// application in an environment.
+type discoveryEnvironment struct {
+	instances  sets.Set[string]
+	components sets.Set[string]
+	versions   sets.Set[string]
 }
 
 func kustomizationRefFromLabels(m map[string]string) *types.NamespacedName {
@@ -152,3 +375,23 @@ func kustomizationRefFromLabels(m map[string]string) *types.NamespacedName {
 	}
 	return &types.NamespacedName{Name: name, Namespace: ns}
 }
+
+// argoApplicationRefFromAnnotations parses the Argo CD tracking annotation
+// which has the format <application>:<group>/<kind>:<namespace>/<name>.
+//
+// Applications outside of the Argo CD namespace are identified as
+// <namespace>_<name>.
+func argoApplicationRefFromAnnotations(m map[string]string) *types.NamespacedName {
+	id, ok := m[ArgoCDTrackingIDAnnotation]
+	if !ok {
+		return nil
+	}
+	app, _, ok := strings.Cut(id, ":")
+	if !ok || app == "" {
+		return nil
+	}
+	if ns, name, ok := strings.Cut(app, "_"); ok {
+		return &types.NamespacedName{Name: name, Namespace: ns}
+	}
+	return &types.NamespacedName{Name: app}
+}
@@ -0,0 +1,167 @@
+package applications
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gitops-tools/apps-scanner/pkg/images"
+	"golang.org/x/exp/maps"
+)
+
+const (
+	versionLabel   = "app.kubernetes.io/version"
+	helmChartLabel = "helm.sh/chart"
+)
+
+// The sources that a Version is taken from.
+const (
+	VersionFromLabel = "label"
+	VersionFromImage = "image"
+	VersionFromChart = "chart"
+)
+
+// Version is the running version of a component of an Application in an
+// instance.
+type Version struct {
+	Component   string `json:"component,omitempty"`
+	Instance    string `json:"instance,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Version     string `json:"version"`
+	// Source is where the version was taken from, one of VersionFromLabel,
+	// VersionFromImage or VersionFromChart.
+	Source string `json:"source"`
+}
+
+// versionOf returns the version of a resource and the source it was taken
+// from, the app.kubernetes.io/version label, the tag or digest of the image of
+// the first container, or the version of the Helm chart.
+func versionOf(labels map[string]string, containerImages []string) (string, string) {
+	if v := labels[versionLabel]; v != "" {
+		return v, VersionFromLabel
+	}
+	if len(containerImages) > 0 {
+		if v := images.Parse(containerImages[0]).Version(); v != "" {
+			return v, VersionFromImage
+		}
+	}
+	if v := chartVersion(labels[helmChartLabel]); v != "" {
+		return v, VersionFromChart
+	}
+	return "", ""
+}
+
+// chartVersion returns the version from a helm.sh/chart label, which has the
+// format <chart name>-<chart version>, the version starts at the first hyphen
+// that is followed by a digit, or by a "v" and a digit.
+func chartVersion(chart string) string {
+	for i := 0; i < len(chart)-1; i++ {
+		if chart[i] != '-' {
+			continue
+		}
+		v := chart[i+1:]
+		if isDigit(v, 0) || (v[0] == 'v' && isDigit(v, 1)) {
+			return v
+		}
+	}
+	return ""
+}
+
+func isDigit(s string, i int) bool {
+	return i < len(s) && s[i] >= '0' && s[i] <= '9'
+}
+
+// VersionMatrix is the versions of the components of Applications in each of
+// the environments, or in each of the instances of Applications without
+// environments.
+type VersionMatrix struct {
+	Columns []string     `json:"columns"`
+	Rows    []VersionRow `json:"rows"`
+}
+
+// VersionRow is the versions of a component of an Application.
+//
+// There is a cell for each of the Columns of the VersionMatrix, in the same
+// order, with the versions running there, which is empty if the component is
+// not running there.
+type VersionRow struct {
+	Application string     `json:"application"`
+	Component   string     `json:"component,omitempty"`
+	Cells       [][]string `json:"cells"`
+}
+
+// NewVersionMatrix returns the VersionMatrix of the versions of the
+// Applications.
+//
+// The columns are in the order provided, followed by any others in
+// alphabetical order.
+func NewVersionMatrix(apps []Application, order ...string) VersionMatrix {
+	known := map[string]bool{}
+	for _, app := range apps {
+		for _, v := range app.Versions {
+			known[versionColumn(v)] = true
+		}
+	}
+	columns := orderNames(known, order)
+	index := map[string]int{}
+	for i, v := range columns {
+		index[v] = i
+	}
+
+	m := VersionMatrix{Columns: columns, Rows: []VersionRow{}}
+	for _, app := range apps {
+		rows := map[string]*VersionRow{}
+		for _, v := range app.Versions {
+			row, ok := rows[v.Component]
+			if !ok {
+				row = &VersionRow{Application: app.Name, Component: v.Component, Cells: make([][]string, len(columns))}
+				rows[v.Component] = row
+			}
+			i := index[versionColumn(v)]
+			if !slices.Contains(row.Cells[i], v.Version) {
+				row.Cells[i] = append(row.Cells[i], v.Version)
+			}
+		}
+		components := maps.Keys(rows)
+		sort.Strings(components)
+		for _, component := range components {
+			m.Rows = append(m.Rows, *rows[component])
+		}
+	}
+	return m
+}
+
+func versionColumn(v Version) string {
+	if v.Environment != "" {
+		return v.Environment
+	}
+	return v.Instance
+}
+
+// orderNames returns the names in the order provided, followed by the others
+// in alphabetical order, names that are ordered but not known are omitted.
+func orderNames(known map[string]bool, order []string) []string {
+	known = maps.Clone(known)
+	var res []string
+	for _, v := range order {
+		if known[v] {
+			res = append(res, v)
+			delete(known, v)
+		}
+	}
+	others := maps.Keys(known)
+	sort.Strings(others)
+	return append(res, others...)
+}
+
+func sortVersions(versions []Version) {
+	sort.Slice(versions, func(i, j int) bool {
+		a, b := versions[i], versions[j]
+		for _, v := range [][2]string{{a.Environment, b.Environment}, {a.Component, b.Component}, {a.Instance, b.Instance}, {a.Version, b.Version}} {
+			if c := strings.Compare(v[0], v[1]); c != 0 {
+				return c < 0
+			}
+		}
+		return a.Source < b.Source
+	})
+}
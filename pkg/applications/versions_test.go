@@ -0,0 +1,168 @@
+package applications
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestParser_versions(t *testing.T) {
+	fromLabel := makePod(inNamespace("sockshop-dev"), withContainers("ghcr.io/sockshop/cart:v1.3.0"), withLabels(map[string]string{
+		nameLabel:      "cart",
+		componentLabel: "backend",
+		instanceLabel:  "cart-dev",
+		versionLabel:   "1.4.0",
+	}))
+	fromImage := makePod(inNamespace("sockshop-prod"), withContainers("ghcr.io/sockshop/cart:v1.2.0", "envoyproxy/envoy:v1.29.0"), withLabels(map[string]string{
+		nameLabel:      "cart",
+		componentLabel: "backend",
+		instanceLabel:  "cart-prod",
+	}))
+	fromChart := makePod(inNamespace("sockshop-prod"), withContainers("redis@sha256:0ed5d5928d4737458944eb604cc8509e245c3e19d02ad83935398bc4b991aac7"), withLabels(map[string]string{
+		nameLabel:      "cart",
+		componentLabel: "cache",
+		instanceLabel:  "cart-prod",
+		helmChartLabel: "redis-18.6.1",
+	}))
+
+	versionTests := []struct {
+		name     string
+		opts     []func(*Parser)
+		want     []Version
+		wantEnvs []Environment
+	}{
+		{
+			name: "not configured",
+		},
+		{
+			name: "detected versions",
+			opts: []func(*Parser){WithVersions()},
+			want: []Version{
+				{Component: "backend", Instance: "cart-dev", Version: "1.4.0", Source: VersionFromLabel},
+				{Component: "backend", Instance: "cart-prod", Version: "v1.2.0", Source: VersionFromImage},
+				{Component: "cache", Instance: "cart-prod", Version: "sha256:0ed5d5928d4737458944eb604cc8509e245c3e19d02ad83935398bc4b991aac7", Source: VersionFromImage},
+			},
+		},
+		{
+			name: "versions in environments",
+			opts: []func(*Parser){WithVersions(), WithEnvironments(Environments{Namespace: regexp.MustCompile(`^sockshop-(.+)$`)})},
+			want: []Version{
+				{Component: "backend", Instance: "cart-dev", Environment: "dev", Version: "1.4.0", Source: VersionFromLabel},
+				{Component: "backend", Instance: "cart-prod", Environment: "prod", Version: "v1.2.0", Source: VersionFromImage},
+				{Component: "cache", Instance: "cart-prod", Environment: "prod", Version: "sha256:0ed5d5928d4737458944eb604cc8509e245c3e19d02ad83935398bc4b991aac7", Source: VersionFromImage},
+			},
+			wantEnvs: []Environment{
+				{Name: "dev", Instances: []string{"cart-dev"}, Components: []string{"backend"}, Versions: []string{"1.4.0"}},
+				{Name: "prod", Instances: []string{"cart-prod"}, Components: []string{"backend", "cache"}, Versions: []string{"sha256:0ed5d5928d4737458944eb604cc8509e245c3e19d02ad83935398bc4b991aac7", "v1.2.0"}},
+			},
+		},
+	}
+
+	for _, tt := range versionTests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.opts...)
+			if err := p.Add([]runtime.Object{fromLabel, fromImage, fromChart}); err != nil {
+				t.Fatal(err)
+			}
+
+			apps := p.Applications()
+			if diff := cmp.Diff(tt.want, apps[0].Versions); diff != "" {
+				t.Fatalf("failed to detect versions:\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantEnvs, apps[0].Environments); diff != "" {
+				t.Fatalf("failed to detect versions in environments:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParser_versions_from_metadata(t *testing.T) {
+	pod := makePod(withContainers("ghcr.io/sockshop/cart:v1.3.0"), withLabels(map[string]string{
+		nameLabel:      "redis",
+		instanceLabel:  "redis",
+		helmChartLabel: "redis-cluster-9.1.4",
+	}))
+	p := NewParser(WithVersions())
+	p.AddObjects([]metav1.Object{pod})
+
+	want := []Version{
+		{Instance: "redis", Version: "9.1.4", Source: VersionFromChart},
+	}
+	if diff := cmp.Diff(want, p.Applications()[0].Versions); diff != "" {
+		t.Fatalf("failed to detect versions:\n%s", diff)
+	}
+}
+
+func TestChartVersion(t *testing.T) {
+	chartTests := []struct {
+		chart string
+		want  string
+	}{
+		{chart: "redis-18.6.1", want: "18.6.1"},
+		{chart: "redis-cluster-9.1.4", want: "9.1.4"},
+		{chart: "podinfo-6.5.4-rc.1", want: "6.5.4-rc.1"},
+		{chart: "cert-manager-v1.13.0", want: "v1.13.0"},
+		{chart: "vault-operator-v", want: ""},
+		{chart: "velero-vmware-2.1.0", want: "2.1.0"},
+		{chart: "podinfo", want: ""},
+		{chart: "podinfo-", want: ""},
+		{chart: "", want: ""},
+	}
+
+	for _, tt := range chartTests {
+		t.Run(tt.chart, func(t *testing.T) {
+			if v := chartVersion(tt.chart); v != tt.want {
+				t.Fatalf("chartVersion(%q) got %q, want %q", tt.chart, v, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewVersionMatrix(t *testing.T) {
+	apps := []Application{
+		{
+			Name: "cart",
+			Versions: []Version{
+				{Component: "backend", Instance: "cart-dev", Environment: "dev", Version: "v1.3.0"},
+				{Component: "backend", Instance: "cart-prod", Environment: "prod", Version: "v1.2.0"},
+				{Component: "backend", Instance: "cart-prod-eu", Environment: "prod", Version: "v1.2.0"},
+				{Component: "backend", Instance: "cart-prod-us", Environment: "prod", Version: "v1.1.0"},
+				{Component: "cache", Instance: "cart-prod", Environment: "prod", Version: "18.6.1"},
+			},
+		},
+		{
+			Name: "orders",
+			Versions: []Version{
+				{Instance: "orders-qa", Version: "v2.0.0"},
+			},
+		},
+		{Name: "sockshop"},
+	}
+
+	m := NewVersionMatrix(apps, "dev", "staging", "prod")
+
+	want := VersionMatrix{
+		Columns: []string{"dev", "prod", "orders-qa"},
+		Rows: []VersionRow{
+			{Application: "cart", Component: "backend", Cells: [][]string{{"v1.3.0"}, {"v1.2.0", "v1.1.0"}, nil}},
+			{Application: "cart", Component: "cache", Cells: [][]string{nil, {"18.6.1"}, nil}},
+			{Application: "orders", Cells: [][]string{nil, nil, {"v2.0.0"}}},
+		},
+	}
+	if diff := cmp.Diff(want, m); diff != "" {
+		t.Fatalf("failed to create version matrix:\n%s", diff)
+	}
+}
+
+func withContainers(images ...string) func(runtime.Object) {
+	return func(obj runtime.Object) {
+		pod := obj.(*corev1.Pod)
+		for _, image := range images {
+			pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Image: image})
+		}
+	}
+}
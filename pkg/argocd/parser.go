@@ -0,0 +1,213 @@
+// Package argocd discovers Argo CD Applications and ApplicationSets, and the
+// Applications that deliver discovered applications.
+package argocd
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+)
+
+var (
+	// GroupVersion is the Argo CD API group version.
+	GroupVersion = schema.GroupVersion{Group: "argoproj.io", Version: "v1alpha1"}
+
+	// ApplicationKind is the GroupVersionKind of an Argo CD Application.
+	ApplicationKind = GroupVersion.WithKind("Application")
+
+	// ApplicationSetKind is the GroupVersionKind of an Argo CD ApplicationSet.
+	ApplicationSetKind = GroupVersion.WithKind("ApplicationSet")
+)
+
+// Application is a summary of an Argo CD Application.
+type Application struct {
+	types.NamespacedName
+	Sources []Source `json:"sources,omitempty"`
+
+	// Revision is the revision that the Application is synced to.
+	Revision string `json:"revision,omitempty"`
+
+	// SyncStatus is the sync status e.g. Synced or OutOfSync.
+	SyncStatus string `json:"syncStatus,omitempty"`
+
+	// HealthStatus is the health of the Application e.g. Healthy.
+	HealthStatus string `json:"healthStatus,omitempty"`
+
+	// ApplicationSet is the name of the ApplicationSet that generated the
+	// Application.
+	ApplicationSet string `json:"applicationSet,omitempty"`
+}
+
+// Source is a source of the manifests for an Application.
+type Source struct {
+	RepoURL        string `json:"repoURL"`
+	Path           string `json:"path,omitempty"`
+	Chart          string `json:"chart,omitempty"`
+	TargetRevision string `json:"targetRevision,omitempty"`
+}
+
+// ApplicationSet is a summary of an Argo CD ApplicationSet.
+type ApplicationSet struct {
+	types.NamespacedName
+	Applications []string `json:"applications,omitempty"`
+}
+
+// Parser parses Argo CD Applications and ApplicationSets.
+type Parser struct {
+	applications    []Application
+	applicationSets map[types.NamespacedName]*ApplicationSet
+}
+
+// NewParser creates and returns a new Parser ready for use.
+func NewParser() *Parser {
+	return &Parser{
+		applicationSets: map[types.NamespacedName]*ApplicationSet{},
+	}
+}
+
+// Add a list of Applications and ApplicationSets to be parsed, other kinds of
+// resource are ignored.
+func (p *Parser) Add(list []unstructured.Unstructured) error {
+	for i := range list {
+		obj := &list[i]
+		if obj.GroupVersionKind().Group != GroupVersion.Group {
+			continue
+		}
+		switch obj.GetKind() {
+		case ApplicationKind.Kind:
+			app, err := applicationFromUnstructured(obj)
+			if err != nil {
+				return fmt.Errorf("failed to parse application %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+			}
+			p.applications = append(p.applications, app)
+		case ApplicationSetKind.Kind:
+			nn := types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}
+			if _, ok := p.applicationSets[nn]; !ok {
+				p.applicationSets[nn] = &ApplicationSet{NamespacedName: nn}
+			}
+		}
+	}
+	return nil
+}
+
+// Applications returns the parsed Applications sorted by namespace and name.
+func (p *Parser) Applications() []Application {
+	res := append([]Application{}, p.applications...)
+	sort.Slice(res, func(i, j int) bool { return res[i].String() < res[j].String() })
+	return res
+}
+
+// ApplicationSets returns the parsed ApplicationSets with the Applications
+// that they generated.
+func (p *Parser) ApplicationSets() []ApplicationSet {
+	res := []ApplicationSet{}
+	for _, v := range p.applicationSets {
+		set := *v
+		for _, app := range p.applications {
+			if app.ApplicationSet == set.Name && app.Namespace == set.Namespace {
+				set.Applications = append(set.Applications, app.Name)
+			}
+		}
+		sort.Strings(set.Applications)
+		res = append(res, set)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].String() < res[j].String() })
+	return res
+}
+
+// Owners returns the Argo CD Applications that deliver the application.
+//
+// Resources are tracked by the tracking-id annotation, or by Argo CD's
+// default instance label, which the applications parser records as an
+// instance.
+func Owners(app applications.Application, argoApps []Application) []Application {
+	var res []Application
+	for _, argoApp := range argoApps {
+		if tracksApplication(app, argoApp) {
+			res = append(res, argoApp)
+		}
+	}
+	return res
+}
+
+func tracksApplication(app applications.Application, argoApp Application) bool {
+	for _, v := range app.ArgoApplications {
+		if v.Name == argoApp.Name && (v.Namespace == "" || v.Namespace == argoApp.Namespace) {
+			return true
+		}
+	}
+	for _, v := range app.Instances {
+		if v == argoApp.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func applicationFromUnstructured(obj *unstructured.Unstructured) (Application, error) {
+	app := Application{
+		NamespacedName: types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()},
+	}
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Kind == ApplicationSetKind.Kind {
+			app.ApplicationSet = ref.Name
+		}
+	}
+
+	source, ok, err := unstructured.NestedMap(obj.Object, "spec", "source")
+	if err != nil {
+		return app, err
+	}
+	if ok {
+		app.Sources = append(app.Sources, sourceFromMap(source))
+	}
+	sources, _, err := unstructured.NestedSlice(obj.Object, "spec", "sources")
+	if err != nil {
+		return app, err
+	}
+	for _, v := range sources {
+		if m, ok := v.(map[string]any); ok {
+			app.Sources = append(app.Sources, sourceFromMap(m))
+		}
+	}
+
+	if app.Revision, _, err = unstructured.NestedString(obj.Object, "status", "sync", "revision"); err != nil {
+		return app, err
+	}
+	if app.Revision == "" {
+		// Multi-source Applications record a revision per source.
+		revisions, _, err := unstructured.NestedStringSlice(obj.Object, "status", "sync", "revisions")
+		if err != nil {
+			return app, err
+		}
+		if len(revisions) > 0 {
+			app.Revision = revisions[0]
+		}
+	}
+	if app.SyncStatus, _, err = unstructured.NestedString(obj.Object, "status", "sync", "status"); err != nil {
+		return app, err
+	}
+	if app.HealthStatus, _, err = unstructured.NestedString(obj.Object, "status", "health", "status"); err != nil {
+		return app, err
+	}
+
+	return app, nil
+}
+
+func sourceFromMap(m map[string]any) Source {
+	str := func(key string) string {
+		s, _ := m[key].(string)
+		return s
+	}
+	return Source{
+		RepoURL:        str("repoURL"),
+		Path:           str("path"),
+		Chart:          str("chart"),
+		TargetRevision: str("targetRevision"),
+	}
+}
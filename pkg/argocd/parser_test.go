@@ -0,0 +1,170 @@
+package argocd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+const guestbookApplication = `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook-dev
+  namespace: argocd
+  ownerReferences:
+  - apiVersion: argoproj.io/v1alpha1
+    kind: ApplicationSet
+    name: guestbook
+    uid: 1234
+spec:
+  source:
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+    path: guestbook
+    targetRevision: HEAD
+status:
+  sync:
+    status: Synced
+    revision: 53e28ff20cc530b9ada2173fbbd64d48338583ba
+  health:
+    status: Healthy
+`
+
+const multiSourceApplication = `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: monitoring
+  namespace: argocd
+spec:
+  sources:
+  - repoURL: https://prometheus-community.github.io/helm-charts
+    chart: prometheus
+    targetRevision: 15.7.1
+  - repoURL: https://github.com/example/monitoring.git
+    path: dashboards
+    targetRevision: main
+status:
+  sync:
+    status: OutOfSync
+    revisions:
+    - 15.7.1
+    - 2f8a1c3
+  health:
+    status: Progressing
+`
+
+const guestbookApplicationSet = `
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: guestbook
+  namespace: argocd
+`
+
+func TestParser(t *testing.T) {
+	p := NewParser()
+	test.AssertNoError(t, p.Add([]unstructured.Unstructured{
+		makeUnstructured(t, multiSourceApplication),
+		makeUnstructured(t, guestbookApplication),
+		makeUnstructured(t, guestbookApplicationSet),
+		makeUnstructured(t, "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: guestbook\n"),
+	}))
+
+	wantApps := []Application{
+		{
+			NamespacedName: types.NamespacedName{Name: "guestbook-dev", Namespace: "argocd"},
+			Sources: []Source{
+				{RepoURL: "https://github.com/argoproj/argocd-example-apps.git", Path: "guestbook", TargetRevision: "HEAD"},
+			},
+			Revision:       "53e28ff20cc530b9ada2173fbbd64d48338583ba",
+			SyncStatus:     "Synced",
+			HealthStatus:   "Healthy",
+			ApplicationSet: "guestbook",
+		},
+		{
+			NamespacedName: types.NamespacedName{Name: "monitoring", Namespace: "argocd"},
+			Sources: []Source{
+				{RepoURL: "https://prometheus-community.github.io/helm-charts", Chart: "prometheus", TargetRevision: "15.7.1"},
+				{RepoURL: "https://github.com/example/monitoring.git", Path: "dashboards", TargetRevision: "main"},
+			},
+			Revision:     "15.7.1",
+			SyncStatus:   "OutOfSync",
+			HealthStatus: "Progressing",
+		},
+	}
+	if diff := cmp.Diff(wantApps, p.Applications()); diff != "" {
+		t.Fatalf("failed to parse applications:\n%s", diff)
+	}
+
+	wantSets := []ApplicationSet{
+		{
+			NamespacedName: types.NamespacedName{Name: "guestbook", Namespace: "argocd"},
+			Applications:   []string{"guestbook-dev"},
+		},
+	}
+	if diff := cmp.Diff(wantSets, p.ApplicationSets()); diff != "" {
+		t.Fatalf("failed to parse application sets:\n%s", diff)
+	}
+}
+
+func TestOwners(t *testing.T) {
+	argoApps := []Application{
+		{NamespacedName: types.NamespacedName{Name: "guestbook", Namespace: "argocd"}},
+		{NamespacedName: types.NamespacedName{Name: "guestbook", Namespace: "team-a"}},
+		{NamespacedName: types.NamespacedName{Name: "monitoring", Namespace: "argocd"}},
+	}
+
+	ownerTests := []struct {
+		name string
+		app  applications.Application
+		want []Application
+	}{
+		{
+			name: "tracked by instance label",
+			app:  applications.Application{Name: "redis", Instances: []string{"monitoring"}},
+			want: []Application{argoApps[2]},
+		},
+		{
+			name: "tracked by annotation",
+			app: applications.Application{
+				Name:             "redis",
+				ArgoApplications: []types.NamespacedName{{Name: "guestbook", Namespace: "team-a"}},
+			},
+			want: []Application{argoApps[1]},
+		},
+		{
+			name: "tracked by annotation without a namespace",
+			app: applications.Application{
+				Name:             "redis",
+				ArgoApplications: []types.NamespacedName{{Name: "guestbook"}},
+			},
+			want: []Application{argoApps[0], argoApps[1]},
+		},
+		{
+			name: "not tracked",
+			app:  applications.Application{Name: "redis", Instances: []string{"redis-abcxyz"}},
+		},
+	}
+
+	for _, tt := range ownerTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, Owners(tt.app, argoApps)); diff != "" {
+				t.Fatalf("failed to find owners:\n%s", diff)
+			}
+		})
+	}
+}
+
+func makeUnstructured(t *testing.T, s string) unstructured.Unstructured {
+	t.Helper()
+	obj := unstructured.Unstructured{}
+	test.AssertNoError(t, yaml.Unmarshal([]byte(s), &obj.Object))
+	return obj
+}
@@ -0,0 +1,198 @@
+// Package backstage converts discovered Applications to Backstage software
+// catalog entities.
+package backstage
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+)
+
+const (
+	apiVersion = "backstage.io/v1alpha1"
+
+	// KubernetesIDAnnotation identifies the Kubernetes resources of a
+	// Component to the Backstage Kubernetes plugin.
+	KubernetesIDAnnotation = "backstage.io/kubernetes-id"
+
+	// KubernetesLabelSelectorAnnotation selects the Kubernetes resources of a
+	// Component for the Backstage Kubernetes plugin.
+	KubernetesLabelSelectorAnnotation = "backstage.io/kubernetes-label-selector"
+
+	// maxTagLength is the longest tag that Backstage accepts.
+	maxTagLength = 63
+)
+
+// invalidTagChars matches the runs of characters that are not permitted in
+// Backstage tags.
+var invalidTagChars = regexp.MustCompile(`[^a-z0-9+#]+`)
+
+// Options configures the fields of the entities that can't be discovered.
+type Options struct {
+	// Owner is the owner of every entity.
+	Owner string
+
+	// Lifecycle is the lifecycle of every Component e.g. production.
+	Lifecycle string
+
+	// Type is the type of every Component e.g. service.
+	Type string
+
+	// NameLabel is the label that identifies the resources of an application.
+	NameLabel string
+}
+
+// Entity is a Backstage catalog entity.
+type Entity struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Metadata   Metadata `json:"metadata"`
+	Spec       Spec     `json:"spec"`
+}
+
+// Metadata is the metadata of a Backstage entity.
+type Metadata struct {
+	Name        string            `json:"name"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+}
+
+// Spec is the union of the System and Component entity specs.
+type Spec struct {
+	Type           string   `json:"type,omitempty"`
+	Lifecycle      string   `json:"lifecycle,omitempty"`
+	Owner          string   `json:"owner"`
+	System         string   `json:"system,omitempty"`
+	SubcomponentOf string   `json:"subcomponentOf,omitempty"`
+	DependsOn      []string `json:"dependsOn,omitempty"`
+}
+
+// Entities converts the Applications to Backstage entities.
+//
+// Top-level applications with children become Systems, and every other
+// application becomes a Component. A Component is part of the nearest System
+// in its parents, and a subcomponent of its first parent that is a Component,
+// which depends on each of its child Components.
+func Entities(apps []applications.Application, opts Options) []Entity {
+	byName := map[string]applications.Application{}
+	children := map[string][]string{}
+	for _, app := range apps {
+		byName[app.Name] = app
+		for _, parent := range app.Parents {
+			children[parent.Name] = append(children[parent.Name], app.Name)
+		}
+	}
+	isSystem := func(name string) bool {
+		return len(byName[name].Parents) == 0 && len(children[name]) > 0
+	}
+
+	var res []Entity
+	for _, app := range apps {
+		if isSystem(app.Name) {
+			res = append(res, Entity{
+				APIVersion: apiVersion,
+				Kind:       "System",
+				Metadata:   Metadata{Name: app.Name},
+				Spec:       Spec{Owner: opts.Owner},
+			})
+			continue
+		}
+
+		e := Entity{
+			APIVersion: apiVersion,
+			Kind:       "Component",
+			Metadata: Metadata{
+				Name: app.Name,
+				Annotations: map[string]string{
+					KubernetesIDAnnotation:            app.Name,
+					KubernetesLabelSelectorAnnotation: opts.NameLabel + "=" + app.Name,
+				},
+				Tags: instanceTags(app.Instances),
+			},
+			Spec: Spec{
+				Type:      opts.Type,
+				Lifecycle: opts.Lifecycle,
+				Owner:     opts.Owner,
+				System:    nearestSystem(app.Name, byName, isSystem, map[string]bool{}),
+			},
+		}
+		for _, parent := range app.Parents {
+			if !isSystem(parent.Name) {
+				e.Spec.SubcomponentOf = "component:" + parent.Name
+				break
+			}
+		}
+		for _, child := range children[app.Name] {
+			e.Spec.DependsOn = append(e.Spec.DependsOn, "component:"+child)
+		}
+		sort.Strings(e.Spec.DependsOn)
+		res = append(res, e)
+	}
+
+	return res
+}
+
+// nearestSystem walks the parents of the named application breadth first to
+// find the closest System.
+func nearestSystem(name string, byName map[string]applications.Application, isSystem func(string) bool, seen map[string]bool) string {
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if seen[current] {
+			continue
+		}
+		seen[current] = true
+		for _, parent := range byName[current].Parents {
+			if isSystem(parent.Name) {
+				return parent.Name
+			}
+			queue = append(queue, parent.Name)
+		}
+	}
+	return ""
+}
+
+// instanceTags converts the instances to Backstage tags, which are lowercase
+// words of a-z, 0-9, + and # separated by hyphens, of up to 63 characters.
+//
+// Other characters are replaced by hyphens, and instances with none of the
+// permitted characters are dropped.
+func instanceTags(instances []string) []string {
+	var tags []string
+	for _, instance := range instances {
+		tag := invalidTagChars.ReplaceAllString(strings.ToLower(instance), "-")
+		if len(tag) > maxTagLength {
+			tag = tag[:maxTagLength]
+		}
+		tag = strings.Trim(tag, "-")
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Write writes the entities as a multi-document catalog-info.yaml.
+func Write(w io.Writer, entities []Entity) error {
+	var b bytes.Buffer
+	for i, e := range entities {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		data, err := yaml.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,144 @@
+package backstage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+var testOptions = Options{
+	Owner:     "team-a",
+	Lifecycle: "production",
+	Type:      "service",
+	NameLabel: "app.kubernetes.io/name",
+}
+
+func TestEntities(t *testing.T) {
+	sockshop := applications.Application{Name: "sockshop"}
+	frontend := applications.Application{
+		Name:      "frontend",
+		Instances: []string{"frontend-dev"},
+		Parents:   []applications.Application{sockshop},
+	}
+	apps := []applications.Application{
+		frontend,
+		{Name: "orders", Parents: []applications.Application{frontend}},
+		{Name: "standalone"},
+		sockshop,
+	}
+
+	entities := Entities(apps, testOptions)
+
+	want := []Entity{
+		{
+			APIVersion: apiVersion,
+			Kind:       "Component",
+			Metadata: Metadata{
+				Name: "frontend",
+				Annotations: map[string]string{
+					KubernetesIDAnnotation:            "frontend",
+					KubernetesLabelSelectorAnnotation: "app.kubernetes.io/name=frontend",
+				},
+				Tags: []string{"frontend-dev"},
+			},
+			Spec: Spec{
+				Type: "service", Lifecycle: "production", Owner: "team-a",
+				System:    "sockshop",
+				DependsOn: []string{"component:orders"},
+			},
+		},
+		{
+			APIVersion: apiVersion,
+			Kind:       "Component",
+			Metadata: Metadata{
+				Name: "orders",
+				Annotations: map[string]string{
+					KubernetesIDAnnotation:            "orders",
+					KubernetesLabelSelectorAnnotation: "app.kubernetes.io/name=orders",
+				},
+			},
+			Spec: Spec{
+				Type: "service", Lifecycle: "production", Owner: "team-a",
+				System:         "sockshop",
+				SubcomponentOf: "component:frontend",
+			},
+		},
+		{
+			APIVersion: apiVersion,
+			Kind:       "Component",
+			Metadata: Metadata{
+				Name: "standalone",
+				Annotations: map[string]string{
+					KubernetesIDAnnotation:            "standalone",
+					KubernetesLabelSelectorAnnotation: "app.kubernetes.io/name=standalone",
+				},
+			},
+			Spec: Spec{Type: "service", Lifecycle: "production", Owner: "team-a"},
+		},
+		{
+			APIVersion: apiVersion,
+			Kind:       "System",
+			Metadata:   Metadata{Name: "sockshop"},
+			Spec:       Spec{Owner: "team-a"},
+		},
+	}
+	if diff := cmp.Diff(want, entities); diff != "" {
+		t.Fatalf("failed to convert entities:\n%s", diff)
+	}
+}
+
+func TestEntities_instance_tags(t *testing.T) {
+	app := applications.Application{
+		Name: "frontend",
+		Instances: []string{
+			"frontend-dev", "Frontend.Staging", "frontend_staging", "", "...",
+			"frontend-" + strings.Repeat("x", 60),
+		},
+	}
+
+	entities := Entities([]applications.Application{app}, testOptions)
+
+	want := []string{"frontend-dev", "frontend-staging", "frontend-" + strings.Repeat("x", 54)}
+	if diff := cmp.Diff(want, entities[0].Metadata.Tags); diff != "" {
+		t.Fatalf("failed to convert instances to tags:\n%s", diff)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	apps := []applications.Application{
+		{Name: "cart", Parents: []applications.Application{{Name: "sockshop"}}},
+		{Name: "sockshop"},
+	}
+
+	var b bytes.Buffer
+	test.AssertNoError(t, Write(&b, Entities(apps, testOptions)))
+
+	want := `apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  annotations:
+    backstage.io/kubernetes-id: cart
+    backstage.io/kubernetes-label-selector: app.kubernetes.io/name=cart
+  name: cart
+spec:
+  lifecycle: production
+  owner: team-a
+  system: sockshop
+  type: service
+---
+apiVersion: backstage.io/v1alpha1
+kind: System
+metadata:
+  name: sockshop
+spec:
+  owner: team-a
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Fatalf("failed to write entities:\n%s", diff)
+	}
+}
@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/gitops-tools/apps-scanner/api/v1alpha1"
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+)
+
+// ApplicationReconciler reconciles the applications discovered from the labels
+// on resources into Application resources.
+type ApplicationReconciler struct {
+	client.Client
+
+	// Kinds are the kinds of resource that are scanned for applications.
+	Kinds []schema.GroupVersionKind
+
+	// Labels are the labels that applications are discovered from.
+	Labels applications.Labels
+}
+
+// The scanned kinds are configurable, the default kinds are permitted here and
+// others must be added to the role.
+//
+// +kubebuilder:rbac:groups=apps.gitops.pro,resources=applications,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps.gitops.pro,resources=applications/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kustomize.toolkit.fluxcd.io,resources=kustomizations,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
+
+// Reconcile creates, updates or deletes the named Application to match the
+// application discovered in the cluster.
+//
+// Applications that are not managed by the scanner are never modified, and
+// applications with names from labels that are not valid object names are
+// skipped.
+func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if !validName(req.Name) {
+		logger.Info("application name is not a valid object name, skipping")
+		return ctrl.Result{}, nil
+	}
+
+	discovered, err := r.discover(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	app, found := findApplication(discovered, req.Name)
+
+	existing := &appsv1alpha1.Application{}
+	err = r.Get(ctx, req.NamespacedName, existing)
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get application %s: %w", req.Name, err)
+	}
+	exists := err == nil
+
+	if exists && !managedByScanner(existing) {
+		logger.Info("application is not managed by the scanner, skipping")
+		return ctrl.Result{}, nil
+	}
+
+	if !found {
+		if !exists {
+			return ctrl.Result{}, nil
+		}
+		logger.Info("application is no longer discovered, deleting")
+		return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, existing))
+	}
+
+	if !exists {
+		existing = &appsv1alpha1.Application{}
+		existing.SetName(app.Name)
+		existing.SetLabels(map[string]string{appsv1alpha1.ManagedByLabel: appsv1alpha1.ManagedByScanner})
+		if err := r.Create(ctx, existing); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to create application %s: %w", app.Name, err)
+		}
+		logger.Info("created application")
+	}
+
+	status, err := r.applicationStatus(ctx, app)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if equality.Semantic.DeepEqual(existing.Status, status) {
+		return ctrl.Result{}, nil
+	}
+	existing.Status = status
+	if err := r.Status().Update(ctx, existing); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update application %s status: %w", app.Name, err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&appsv1alpha1.Application{}).
+		Watches(&kustomizev1.Kustomization{}, handler.EnqueueRequestsFromMapFunc(r.applicationsForKustomization))
+
+	for _, gvk := range r.Kinds {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		b = b.Watches(obj, handler.EnqueueRequestsFromMapFunc(r.applicationsForResource))
+	}
+
+	return b.Complete(r)
+}
+
+// discover parses the applications from the resources in the cluster.
+func (r *ApplicationReconciler) discover(ctx context.Context) ([]applications.Application, error) {
+	p := applications.NewParser(applications.WithLabels(
+		r.Labels.Name, r.Labels.PartOf, r.Labels.Instance, r.Labels.Component))
+
+	for _, gvk := range r.Kinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := r.List(ctx, list, client.HasLabels{r.Labels.PartOf}); err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", gvk.GroupKind(), err)
+		}
+
+		p.AddUnstructured(list.Items)
+	}
+
+	return p.Applications(), nil
+}
+
+func (r *ApplicationReconciler) applicationStatus(ctx context.Context, app applications.Application) (appsv1alpha1.ApplicationStatus, error) {
+	status := appsv1alpha1.ApplicationStatus{
+		Components: app.Components,
+		Instances:  app.Instances,
+		Health:     string(flux.HealthUnknown),
+	}
+	for _, v := range app.Parents {
+		status.Parents = append(status.Parents, v.Name)
+	}
+
+	var health []flux.Health
+	for _, nn := range app.Kustomizations {
+		kustomization := &kustomizev1.Kustomization{}
+		h := flux.HealthUnknown
+		if err := r.Get(ctx, nn, kustomization); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return status, fmt.Errorf("failed to get kustomization %s: %w", nn, err)
+			}
+		} else {
+			h = flux.HealthFromConditions(kustomization.Status.Conditions)
+		}
+		health = append(health, h)
+		status.Kustomizations = append(status.Kustomizations, appsv1alpha1.KustomizationReference{
+			Name:      nn.Name,
+			Namespace: nn.Namespace,
+			Health:    string(h),
+		})
+	}
+	if len(health) > 0 {
+		status.Health = string(leastHealthy(health))
+	}
+
+	return status, nil
+}
+
+// applicationsForResource maps a scanned resource to the applications that it
+// is labelled with.
+func (r *ApplicationReconciler) applicationsForResource(ctx context.Context, obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	labels := obj.GetLabels()
+	for _, label := range []string{r.Labels.Name, r.Labels.PartOf} {
+		if name := labels[label]; name != "" && validName(name) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+		}
+	}
+
+	return requests
+}
+
+// applicationsForKustomization maps a Kustomization to the Applications that
+// it delivers so that their health is updated.
+func (r *ApplicationReconciler) applicationsForKustomization(ctx context.Context, obj client.Object) []reconcile.Request {
+	apps := &appsv1alpha1.ApplicationList{}
+	if err := r.List(ctx, apps); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list applications")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, app := range apps.Items {
+		for _, v := range app.Status.Kustomizations {
+			if v.Name == obj.GetName() && v.Namespace == obj.GetNamespace() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name}})
+				break
+			}
+		}
+	}
+
+	return requests
+}
+
+func findApplication(apps []applications.Application, name string) (applications.Application, bool) {
+	for _, v := range apps {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return applications.Application{}, false
+}
+
+// validName returns true if the name of an application is a valid name for an
+// Application, the labels that names come from can have uppercase letters and
+// underscores.
+func validName(name string) bool {
+	return len(validation.IsDNS1123Subdomain(name)) == 0
+}
+
+func managedByScanner(app *appsv1alpha1.Application) bool {
+	return app.GetLabels()[appsv1alpha1.ManagedByLabel] == appsv1alpha1.ManagedByScanner
+}
+
+// leastHealthy returns the worst of the health states, an Unhealthy
+// Kustomization makes the application Unhealthy.
+func leastHealthy(health []flux.Health) flux.Health {
+	rank := map[flux.Health]int{
+		flux.HealthHealthy:     0,
+		flux.HealthUnknown:     1,
+		flux.HealthProgressing: 2,
+		flux.HealthUnhealthy:   3,
+	}
+	worst := flux.HealthHealthy
+	for _, v := range health {
+		if rank[v] > rank[worst] {
+			worst = v
+		}
+	}
+	return worst
+}
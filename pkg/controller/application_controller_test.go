@@ -0,0 +1,270 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1alpha1 "github.com/gitops-tools/apps-scanner/api/v1alpha1"
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+func TestReconcile_creates_application(t *testing.T) {
+	r := makeReconciler(t,
+		makeDeployment("cart", withLabels(map[string]string{
+			"app.kubernetes.io/name":                "cart",
+			"app.kubernetes.io/part-of":             "sockshop",
+			"app.kubernetes.io/instance":            "cart-dev",
+			"app.kubernetes.io/component":           "backend",
+			"kustomize.toolkit.fluxcd.io/name":      "sockshop-dev",
+			"kustomize.toolkit.fluxcd.io/namespace": "default",
+		})),
+		makeKustomization("sockshop-dev", "default", metav1.ConditionTrue))
+
+	reconcileApplication(t, r, "cart")
+
+	app := getApplication(t, r, "cart")
+	want := appsv1alpha1.ApplicationStatus{
+		Components: []string{"backend"},
+		Instances:  []string{"cart-dev"},
+		Parents:    []string{"sockshop"},
+		Kustomizations: []appsv1alpha1.KustomizationReference{
+			{Name: "sockshop-dev", Namespace: "default", Health: string(flux.HealthHealthy)},
+		},
+		Health: string(flux.HealthHealthy),
+	}
+	if diff := cmp.Diff(want, app.Status); diff != "" {
+		t.Fatalf("failed to reconcile application:\n%s", diff)
+	}
+	if !managedByScanner(app) {
+		t.Fatalf("application is not labelled as managed by the scanner: %v", app.GetLabels())
+	}
+}
+
+func TestReconcile_creates_parent_application(t *testing.T) {
+	r := makeReconciler(t,
+		makeDeployment("cart", withLabels(map[string]string{
+			"app.kubernetes.io/name":    "cart",
+			"app.kubernetes.io/part-of": "sockshop",
+		})))
+
+	reconcileApplication(t, r, "sockshop")
+
+	app := getApplication(t, r, "sockshop")
+	want := appsv1alpha1.ApplicationStatus{
+		Health: string(flux.HealthUnknown),
+	}
+	if diff := cmp.Diff(want, app.Status); diff != "" {
+		t.Fatalf("failed to reconcile application:\n%s", diff)
+	}
+}
+
+func TestReconcile_updates_health(t *testing.T) {
+	r := makeReconciler(t,
+		makeDeployment("cart", withLabels(map[string]string{
+			"app.kubernetes.io/name":                "cart",
+			"app.kubernetes.io/part-of":             "sockshop",
+			"kustomize.toolkit.fluxcd.io/name":      "sockshop-dev",
+			"kustomize.toolkit.fluxcd.io/namespace": "default",
+		})),
+		makeKustomization("sockshop-dev", "default", metav1.ConditionTrue))
+	reconcileApplication(t, r, "cart")
+
+	kustomization := &kustomizev1.Kustomization{}
+	test.AssertNoError(t, r.Get(context.TODO(), types.NamespacedName{Name: "sockshop-dev", Namespace: "default"}, kustomization))
+	kustomization.Status.Conditions[0].Status = metav1.ConditionFalse
+	test.AssertNoError(t, r.Status().Update(context.TODO(), kustomization))
+	reconcileApplication(t, r, "cart")
+
+	app := getApplication(t, r, "cart")
+	if app.Status.Health != string(flux.HealthUnhealthy) {
+		t.Fatalf("got health %q, want %q", app.Status.Health, flux.HealthUnhealthy)
+	}
+}
+
+func TestReconcile_deletes_undiscovered_application(t *testing.T) {
+	r := makeReconciler(t, makeApplication("cart", withLabels(map[string]string{
+		appsv1alpha1.ManagedByLabel: appsv1alpha1.ManagedByScanner,
+	})))
+
+	reconcileApplication(t, r, "cart")
+
+	err := r.Get(context.TODO(), types.NamespacedName{Name: "cart"}, &appsv1alpha1.Application{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("got error %v, want not found", err)
+	}
+}
+
+func TestReconcile_ignores_unmanaged_application(t *testing.T) {
+	r := makeReconciler(t, makeApplication("cart"))
+
+	reconcileApplication(t, r, "cart")
+
+	app := getApplication(t, r, "cart")
+	if diff := cmp.Diff(appsv1alpha1.ApplicationStatus{}, app.Status); diff != "" {
+		t.Fatalf("unmanaged application was modified:\n%s", diff)
+	}
+}
+
+func TestReconcile_skips_invalid_name(t *testing.T) {
+	r := makeReconciler(t,
+		makeDeployment("cart", withLabels(map[string]string{
+			"app.kubernetes.io/name":    "Cart_Service",
+			"app.kubernetes.io/part-of": "sockshop",
+		})))
+
+	reconcileApplication(t, r, "Cart_Service")
+
+	err := r.Get(context.TODO(), types.NamespacedName{Name: "Cart_Service"}, &appsv1alpha1.Application{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("got error %v, want not found", err)
+	}
+}
+
+func TestApplicationsForResource(t *testing.T) {
+	r := makeReconciler(t)
+	obj := makeDeployment("cart", withLabels(map[string]string{
+		"app.kubernetes.io/name":    "cart",
+		"app.kubernetes.io/part-of": "sockshop",
+	}))
+
+	requests := r.applicationsForResource(context.TODO(), obj)
+
+	want := []ctrl.Request{
+		{NamespacedName: types.NamespacedName{Name: "cart"}},
+		{NamespacedName: types.NamespacedName{Name: "sockshop"}},
+	}
+	if diff := cmp.Diff(want, requests); diff != "" {
+		t.Fatalf("failed to map resource:\n%s", diff)
+	}
+}
+
+func TestApplicationsForResource_invalid_name(t *testing.T) {
+	r := makeReconciler(t)
+	obj := makeDeployment("cart", withLabels(map[string]string{
+		"app.kubernetes.io/name":    "Cart_Service",
+		"app.kubernetes.io/part-of": "sockshop",
+	}))
+
+	requests := r.applicationsForResource(context.TODO(), obj)
+
+	want := []ctrl.Request{
+		{NamespacedName: types.NamespacedName{Name: "sockshop"}},
+	}
+	if diff := cmp.Diff(want, requests); diff != "" {
+		t.Fatalf("failed to map resource:\n%s", diff)
+	}
+}
+
+func TestLeastHealthy(t *testing.T) {
+	healthTests := []struct {
+		health []flux.Health
+		want   flux.Health
+	}{
+		{health: []flux.Health{flux.HealthHealthy}, want: flux.HealthHealthy},
+		{health: []flux.Health{flux.HealthHealthy, flux.HealthUnknown}, want: flux.HealthUnknown},
+		{health: []flux.Health{flux.HealthProgressing, flux.HealthUnknown}, want: flux.HealthProgressing},
+		{health: []flux.Health{flux.HealthUnhealthy, flux.HealthProgressing}, want: flux.HealthUnhealthy},
+	}
+
+	for _, tt := range healthTests {
+		t.Run(string(tt.want), func(t *testing.T) {
+			if h := leastHealthy(tt.health); h != tt.want {
+				t.Fatalf("got %q, want %q", h, tt.want)
+			}
+		})
+	}
+}
+
+func makeReconciler(t *testing.T, objs ...client.Object) *ApplicationReconciler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	test.AssertNoError(t, clientgoscheme.AddToScheme(scheme))
+	test.AssertNoError(t, kustomizev1.AddToScheme(scheme))
+	test.AssertNoError(t, appsv1alpha1.AddToScheme(scheme))
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&appsv1alpha1.Application{}, &kustomizev1.Kustomization{}).
+		Build()
+
+	return &ApplicationReconciler{
+		Client: cl,
+		Kinds:  []schema.GroupVersionKind{appsv1.SchemeGroupVersion.WithKind("Deployment")},
+		Labels: applications.NewParser().Labels,
+	}
+}
+
+func reconcileApplication(t *testing.T, r *ApplicationReconciler, name string) {
+	t.Helper()
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+	test.AssertNoError(t, err)
+}
+
+func getApplication(t *testing.T, r *ApplicationReconciler, name string) *appsv1alpha1.Application {
+	t.Helper()
+	app := &appsv1alpha1.Application{}
+	test.AssertNoError(t, r.Get(context.TODO(), types.NamespacedName{Name: name}, app))
+	return app
+}
+
+func withLabels(m map[string]string) func(client.Object) {
+	return func(obj client.Object) {
+		obj.SetLabels(m)
+	}
+}
+
+func makeDeployment(name string, opts ...func(client.Object)) *appsv1.Deployment {
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+func makeApplication(name string, opts ...func(client.Object)) *appsv1alpha1.Application {
+	a := &appsv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	for _, o := range opts {
+		o(a)
+	}
+	return a
+}
+
+func makeKustomization(name, namespace string, ready metav1.ConditionStatus) *kustomizev1.Kustomization {
+	return &kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Status: kustomizev1.KustomizationStatus{
+			Conditions: []metav1.Condition{
+				{Type: fluxmeta.ReadyCondition, Status: ready},
+			},
+		},
+	}
+}
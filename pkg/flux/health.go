@@ -0,0 +1,45 @@
+package flux
+
+import (
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Health is a summary of the Ready condition of a Flux object.
+type Health string
+
+const (
+	// HealthUnknown indicates that the object has no Ready condition.
+	HealthUnknown Health = "Unknown"
+
+	// HealthHealthy indicates that the object is Ready.
+	HealthHealthy Health = "Healthy"
+
+	// HealthUnhealthy indicates that the object failed to become Ready.
+	HealthUnhealthy Health = "Unhealthy"
+
+	// HealthProgressing indicates that the object is being reconciled.
+	HealthProgressing Health = "Progressing"
+)
+
+// HealthFromConditions summarises the Ready condition in a set of conditions.
+func HealthFromConditions(conditions []metav1.Condition) Health {
+	ready := meta.FindStatusCondition(conditions, fluxmeta.ReadyCondition)
+	if ready == nil {
+		return HealthUnknown
+	}
+
+	switch ready.Status {
+	case metav1.ConditionTrue:
+		return HealthHealthy
+	case metav1.ConditionFalse:
+		return HealthUnhealthy
+	}
+
+	if ready.Reason == fluxmeta.ProgressingReason {
+		return HealthProgressing
+	}
+
+	return HealthUnknown
+}
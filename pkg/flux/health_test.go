@@ -0,0 +1,53 @@
+package flux
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHealthFromConditions(t *testing.T) {
+	healthTests := []struct {
+		name       string
+		conditions []metav1.Condition
+		want       Health
+	}{
+		{
+			name: "no conditions",
+			want: HealthUnknown,
+		},
+		{
+			name:       "no ready condition",
+			conditions: []metav1.Condition{{Type: "Reconciling", Status: metav1.ConditionTrue}},
+			want:       HealthUnknown,
+		},
+		{
+			name:       "ready",
+			conditions: []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue}},
+			want:       HealthHealthy,
+		},
+		{
+			name:       "not ready",
+			conditions: []metav1.Condition{{Type: "Ready", Status: metav1.ConditionFalse}},
+			want:       HealthUnhealthy,
+		},
+		{
+			name:       "progressing",
+			conditions: []metav1.Condition{{Type: "Ready", Status: metav1.ConditionUnknown, Reason: "Progressing"}},
+			want:       HealthProgressing,
+		},
+		{
+			name:       "unknown ready",
+			conditions: []metav1.Condition{{Type: "Ready", Status: metav1.ConditionUnknown}},
+			want:       HealthUnknown,
+		},
+	}
+
+	for _, tt := range healthTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if h := HealthFromConditions(tt.conditions); h != tt.want {
+				t.Fatalf("got %q, want %q", h, tt.want)
+			}
+		})
+	}
+}
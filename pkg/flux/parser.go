@@ -12,14 +12,14 @@ import (
 
 // Repository is a summarised version of a list of GitRepository objects.
 type Repository struct {
-	URL  string
-	Refs []RepositoryRef
+	URL  string          `json:"url"`
+	Refs []RepositoryRef `json:"refs,omitempty"`
 }
 
 // RepositoryRef indicates which ref a specific GitRepository is tracking.
 type RepositoryRef struct {
 	types.NamespacedName
-	Ref sourcev1.GitRepositoryRef
+	Ref sourcev1.GitRepositoryRef `json:"ref"`
 }
 
 // Parser parses a list of Kustomization objects and extracts information from
@@ -47,7 +47,11 @@ func (p *Parser) Add(list []sourcev1.GitRepository) error {
 				refs: newRepositoryRefSet(),
 			}
 		}
-		k.refs.Insert(RepositoryRef{NamespacedName: namespacedNameFromRepository(repo), Ref: *repo.Spec.Reference})
+		ref := RepositoryRef{NamespacedName: namespacedNameFromRepository(repo)}
+		if repo.Spec.Reference != nil {
+			ref.Ref = *repo.Spec.Reference
+		}
+		k.refs.Insert(ref)
 		p.repositories[repo.Spec.URL] = k
 	}
 	return nil
@@ -62,9 +66,28 @@ func (p *Parser) Repositories() []Repository {
 			Refs: v.refs.List(),
 		})
 	}
+	sort.Slice(res, func(i, j int) bool { return res[i].URL < res[j].URL })
 	return res
 }
 
+// DescribeRef formats the ref that a GitRepository tracks, using the field that
+// takes precedence when Flux checks out the repository.
+//
+// If no ref is set this returns an empty string.
+func DescribeRef(ref sourcev1.GitRepositoryRef) string {
+	switch {
+	case ref.Commit != "":
+		return "commit " + ref.Commit
+	case ref.SemVer != "":
+		return "semver " + ref.SemVer
+	case ref.Tag != "":
+		return "tag " + ref.Tag
+	case ref.Branch != "":
+		return "branch " + ref.Branch
+	}
+	return ""
+}
+
 // discoveryRepository is a temporary holding type to simplify identification
 // of repositories.
 type discoveryRepository struct {
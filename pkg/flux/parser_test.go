@@ -38,6 +38,22 @@ func TestParser(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "repository with no reference",
+			items: [][]sourcev1.GitRepository{
+				{
+					makeGitRepository(withURL("git@github.com:demo/demo-repo.git"), named("test", "test-ns")),
+				},
+			},
+			want: []Repository{
+				Repository{
+					URL: "git@github.com:demo/demo-repo.git",
+					Refs: []RepositoryRef{
+						{NamespacedName: types.NamespacedName{Name: "test", Namespace: "test-ns"}},
+					},
+				},
+			},
+		},
 		{
 			name: "multiple refs for a repository",
 			items: [][]sourcev1.GitRepository{
@@ -154,3 +170,24 @@ func sortOpts() []cmp.Option {
 			}),
 	}
 }
+
+func TestDescribeRef(t *testing.T) {
+	refTests := []struct {
+		ref  sourcev1.GitRepositoryRef
+		want string
+	}{
+		{ref: sourcev1.GitRepositoryRef{}, want: ""},
+		{ref: sourcev1.GitRepositoryRef{Branch: "main"}, want: "branch main"},
+		{ref: sourcev1.GitRepositoryRef{Branch: "main", Tag: "v1.0.0"}, want: "tag v1.0.0"},
+		{ref: sourcev1.GitRepositoryRef{Tag: "v1.0.0", SemVer: ">= 1.0.0"}, want: "semver >= 1.0.0"},
+		{ref: sourcev1.GitRepositoryRef{Branch: "main", Commit: "abc123"}, want: "commit abc123"},
+	}
+
+	for _, tt := range refTests {
+		t.Run(tt.want, func(t *testing.T) {
+			if s := DescribeRef(tt.ref); s != tt.want {
+				t.Fatalf("got %q, want %q", s, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+)
+
+const (
+	// snapshots are named by the time they were taken so that they can be
+	// sorted.
+	timestampFormat = "20060102T150405Z"
+	snapshotSuffix  = ".json"
+)
+
+// ErrNoSnapshot is returned when there is no snapshot for a query.
+var ErrNoSnapshot = errors.New("no snapshot found")
+
+// Snapshot is an Inventory that was scanned at a specific time.
+type Snapshot struct {
+	Time      time.Time
+	Inventory inventory.Inventory
+}
+
+// Sighting records where a name was found in a snapshot.
+type Sighting struct {
+	Time time.Time
+	// Kind is one of "application", "component" or "instance".
+	Kind        string
+	Application string
+}
+
+// Store records snapshots of Inventories in a directory, with one file per
+// snapshot.
+type Store struct {
+	dir string
+}
+
+// NewStore creates and returns a new Store that records snapshots in dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Save records the Inventory as a snapshot at the time.
+//
+// Times are truncated to the second, saving a second snapshot with the same
+// time will replace the first.
+func (s *Store) Save(t time.Time, inv inventory.Inventory) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	filename := filepath.Join(s.dir, t.UTC().Format(timestampFormat)+snapshotSuffix)
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	defer f.Close()
+
+	if err := inventory.Write(f, inv); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Times returns the times of the recorded snapshots in chronological order.
+func (s *Store) Times() ([]time.Time, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var res []time.Time
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, snapshotSuffix) {
+			continue
+		}
+		t, err := time.Parse(timestampFormat, strings.TrimSuffix(name, snapshotSuffix))
+		if err != nil {
+			// Ignore files that weren't written by the Store.
+			continue
+		}
+		res = append(res, t)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Before(res[j]) })
+
+	return res, nil
+}
+
+// Load reads the snapshot recorded at the time.
+func (s *Store) Load(t time.Time) (*Snapshot, error) {
+	inv, err := inventory.ReadFile(filepath.Join(s.dir, t.UTC().Format(timestampFormat)+snapshotSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{Time: t.UTC(), Inventory: *inv}, nil
+}
+
+// At returns the latest snapshot that was recorded at or before the time.
+//
+// If no snapshot was recorded before the time, ErrNoSnapshot is returned.
+func (s *Store) At(t time.Time) (*Snapshot, error) {
+	times, err := s.Times()
+	if err != nil {
+		return nil, err
+	}
+
+	i := sort.Search(len(times), func(i int) bool { return times[i].After(t) })
+	if i == 0 {
+		return nil, fmt.Errorf("%w at %s", ErrNoSnapshot, t.Format(time.RFC3339))
+	}
+
+	return s.Load(times[i-1])
+}
+
+// FirstSeen returns where the name was first seen in the recorded snapshots.
+//
+// The name is matched against the names of Applications, and their components
+// and instances.
+//
+// If the name was never seen, ErrNoSnapshot is returned.
+func (s *Store) FirstSeen(name string) (*Sighting, error) {
+	times, err := s.Times()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range times {
+		snapshot, err := s.Load(t)
+		if err != nil {
+			return nil, err
+		}
+		if sighting := findName(snapshot.Inventory.Applications, name); sighting != nil {
+			sighting.Time = t
+			return sighting, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w containing %q", ErrNoSnapshot, name)
+}
+
+func findName(apps []applications.Application, name string) *Sighting {
+	for _, app := range apps {
+		if app.Name == name {
+			return &Sighting{Kind: "application", Application: app.Name}
+		}
+	}
+	for _, app := range apps {
+		for _, c := range app.Components {
+			if c == name {
+				return &Sighting{Kind: "component", Application: app.Name}
+			}
+		}
+		for _, i := range app.Instances {
+			if i == name {
+				return &Sighting{Kind: "instance", Application: app.Name}
+			}
+		}
+	}
+	return nil
+}
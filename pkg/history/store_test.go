@@ -0,0 +1,159 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+var (
+	firstScan  = time.Date(2026, time.August, 1, 2, 0, 0, 0, time.UTC)
+	secondScan = time.Date(2026, time.September, 1, 2, 0, 0, 0, time.UTC)
+)
+
+func TestStore_Times(t *testing.T) {
+	s := makeStore(t)
+	// Files that weren't written by the store are ignored.
+	test.AssertNoError(t, os.WriteFile(filepath.Join(s.dir, "notes.json"), []byte("{}"), 0644))
+
+	times, err := s.Times()
+	test.AssertNoError(t, err)
+
+	if diff := cmp.Diff([]time.Time{firstScan, secondScan}, times); diff != "" {
+		t.Fatalf("failed to list snapshots:\n%s", diff)
+	}
+}
+
+func TestStore_Times_missing_directory(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "missing"))
+
+	times, err := s.Times()
+	test.AssertNoError(t, err)
+
+	if times != nil {
+		t.Fatalf("got %v, want no snapshots", times)
+	}
+}
+
+func TestStore_At(t *testing.T) {
+	s := makeStore(t)
+
+	atTests := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{name: "exact time", at: firstScan, want: firstScan},
+		{name: "between snapshots", at: secondScan.Add(-time.Hour), want: firstScan},
+		{name: "after the last snapshot", at: secondScan.Add(time.Hour), want: secondScan},
+	}
+
+	for _, tt := range atTests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot, err := s.At(tt.at)
+			test.AssertNoError(t, err)
+
+			if !snapshot.Time.Equal(tt.want) {
+				t.Fatalf("got snapshot at %s, want %s", snapshot.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_At_before_first_snapshot(t *testing.T) {
+	s := makeStore(t)
+
+	_, err := s.At(firstScan.Add(-time.Hour))
+
+	if !errors.Is(err, ErrNoSnapshot) {
+		t.Fatalf("got %v, want ErrNoSnapshot", err)
+	}
+}
+
+func TestStore_At_returns_inventory(t *testing.T) {
+	s := makeStore(t)
+
+	snapshot, err := s.At(secondScan)
+	test.AssertNoError(t, err)
+
+	want := []applications.Application{
+		{Name: "sockshop"},
+		{
+			Name:       "orders",
+			Components: []string{"orders", "orders-db"},
+			Instances:  []string{"orders-dev"},
+			Parents:    []applications.Application{{Name: "sockshop"}},
+		},
+	}
+	if diff := cmp.Diff(want, snapshot.Inventory.Applications); diff != "" {
+		t.Fatalf("failed to load snapshot:\n%s", diff)
+	}
+}
+
+func TestStore_FirstSeen(t *testing.T) {
+	s := makeStore(t)
+
+	seenTests := []struct {
+		name string
+		want Sighting
+	}{
+		{name: "sockshop", want: Sighting{Time: firstScan, Kind: "application", Application: "sockshop"}},
+		{name: "orders", want: Sighting{Time: firstScan, Kind: "application", Application: "orders"}},
+		{name: "orders-db", want: Sighting{Time: secondScan, Kind: "component", Application: "orders"}},
+		{name: "orders-dev", want: Sighting{Time: firstScan, Kind: "instance", Application: "orders"}},
+	}
+
+	for _, tt := range seenTests {
+		t.Run(tt.name, func(t *testing.T) {
+			sighting, err := s.FirstSeen(tt.name)
+			test.AssertNoError(t, err)
+
+			if diff := cmp.Diff(tt.want, *sighting); diff != "" {
+				t.Fatalf("failed to find first sighting:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStore_FirstSeen_unknown_name(t *testing.T) {
+	s := makeStore(t)
+
+	_, err := s.FirstSeen("unknown")
+
+	test.AssertErrorMatch(t, `no snapshot found containing "unknown"`, err)
+}
+
+func makeStore(t *testing.T) *Store {
+	s := NewStore(t.TempDir())
+	test.AssertNoError(t, s.Save(firstScan, inventory.Inventory{
+		Applications: []applications.Application{
+			{Name: "sockshop"},
+			{
+				Name:       "orders",
+				Components: []string{"orders"},
+				Instances:  []string{"orders-dev"},
+				Parents:    []applications.Application{{Name: "sockshop"}},
+			},
+		},
+	}))
+	test.AssertNoError(t, s.Save(secondScan, inventory.Inventory{
+		Applications: []applications.Application{
+			{Name: "sockshop"},
+			{
+				Name:       "orders",
+				Components: []string{"orders", "orders-db"},
+				Instances:  []string{"orders-dev"},
+				Parents:    []applications.Application{{Name: "sockshop"}},
+			},
+		},
+	}))
+	return s
+}
@@ -0,0 +1,97 @@
+// Package images parses the container images of workloads.
+package images
+
+import (
+	"strings"
+)
+
+// DefaultRegistry is the registry of images without a registry.
+const DefaultRegistry = "docker.io"
+
+// Reference is a parsed container image reference.
+type Reference struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+// Parse parses an image reference of the form
+// [registry/]repository[:tag][@digest].
+//
+// Images without a registry are in the DefaultRegistry, and repositories in
+// the DefaultRegistry without a namespace are in the library namespace, in
+// the same way as they are pulled.
+func Parse(image string) Reference {
+	var ref Reference
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i:], "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+
+	ref.Registry = DefaultRegistry
+	if first, rest, ok := strings.Cut(name, "/"); ok && isRegistry(first) {
+		ref.Registry = first
+		name = rest
+	}
+	if ref.Registry == DefaultRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+	return ref
+}
+
+// Version returns the tag of the image, or the digest if there is no tag.
+func (r Reference) Version() string {
+	if r.Tag != "" {
+		return r.Tag
+	}
+	return r.Digest
+}
+
+// The ways that an image reference can identify an image.
+const (
+	// PinnedByDigest is a reference with a digest, which always identifies
+	// the same image.
+	PinnedByDigest = "digest"
+	// PinnedByTag is a reference with a tag and no digest, the image can
+	// change if the tag is moved.
+	PinnedByTag = "tag"
+	// Unpinned is a reference without a tag or digest, which is the latest
+	// image.
+	Unpinned = "none"
+)
+
+// Pinning returns how the reference identifies the image, PinnedByDigest,
+// PinnedByTag or Unpinned.
+func (r Reference) Pinning() string {
+	switch {
+	case r.Digest != "":
+		return PinnedByDigest
+	case r.Tag != "":
+		return PinnedByTag
+	}
+	return Unpinned
+}
+
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// isRegistry returns true if the first component of an image name is a
+// registry host rather than a namespace in the default registry.
+func isRegistry(s string) bool {
+	return strings.ContainsAny(s, ".:") || s == "localhost"
+}
@@ -0,0 +1,92 @@
+package images
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	parseTests := []struct {
+		image string
+		want  Reference
+	}{
+		{
+			image: "nginx",
+			want:  Reference{Registry: "docker.io", Repository: "library/nginx"},
+		},
+		{
+			image: "nginx:1.25",
+			want:  Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.25"},
+		},
+		{
+			image: "weaveworksdemos/carts:0.4.8",
+			want:  Reference{Registry: "docker.io", Repository: "weaveworksdemos/carts", Tag: "0.4.8"},
+		},
+		{
+			image: "ghcr.io/example/cart:v1.2.3",
+			want:  Reference{Registry: "ghcr.io", Repository: "example/cart", Tag: "v1.2.3"},
+		},
+		{
+			image: "localhost:5000/cart",
+			want:  Reference{Registry: "localhost:5000", Repository: "cart"},
+		},
+		{
+			image: "ghcr.io/example/cart@sha256:4a5b6c",
+			want:  Reference{Registry: "ghcr.io", Repository: "example/cart", Digest: "sha256:4a5b6c"},
+		},
+		{
+			image: "registry.example.com:443/team/cart:1.0@sha256:4a5b6c",
+			want:  Reference{Registry: "registry.example.com:443", Repository: "team/cart", Tag: "1.0", Digest: "sha256:4a5b6c"},
+		},
+	}
+
+	for _, tt := range parseTests {
+		t.Run(tt.image, func(t *testing.T) {
+			ref := Parse(tt.image)
+			if diff := cmp.Diff(tt.want, ref); diff != "" {
+				t.Fatalf("failed to parse image:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReference_Version(t *testing.T) {
+	versionTests := []struct {
+		image string
+		want  string
+	}{
+		{image: "nginx", want: ""},
+		{image: "nginx:1.25", want: "1.25"},
+		{image: "nginx@sha256:4a5b6c", want: "sha256:4a5b6c"},
+		{image: "nginx:1.25@sha256:4a5b6c", want: "1.25"},
+	}
+
+	for _, tt := range versionTests {
+		t.Run(tt.image, func(t *testing.T) {
+			if v := Parse(tt.image).Version(); v != tt.want {
+				t.Fatalf("got version %q, want %q", v, tt.want)
+			}
+		})
+	}
+}
+
+func TestReference_Pinning(t *testing.T) {
+	pinningTests := []struct {
+		image string
+		want  string
+	}{
+		{image: "nginx", want: Unpinned},
+		{image: "nginx:1.25", want: PinnedByTag},
+		{image: "nginx@sha256:4a5b6c", want: PinnedByDigest},
+		{image: "nginx:1.25@sha256:4a5b6c", want: PinnedByDigest},
+	}
+
+	for _, tt := range pinningTests {
+		t.Run(tt.image, func(t *testing.T) {
+			if v := Parse(tt.image).Pinning(); v != tt.want {
+				t.Fatalf("got pinning %q, want %q", v, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package images
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+
+	"golang.org/x/exp/maps"
+)
+
+// Usage is the image of a container in a workload of an Application.
+type Usage struct {
+	Application string `json:"application"`
+	Component   string `json:"component,omitempty"`
+	Instance    string `json:"instance,omitempty"`
+	// Workload identifies the resource with the container as
+	// <kind>/<namespace>/<name>.
+	Workload  string `json:"workload"`
+	Container string `json:"container"`
+	Init      bool   `json:"init,omitempty"`
+	Image     string `json:"image"`
+}
+
+// Report is the images that are used by Applications.
+type Report struct {
+	Images     []ApplicationImage `json:"images"`
+	Registries []Registry         `json:"registries"`
+	Shared     []SharedImage      `json:"shared,omitempty"`
+}
+
+// ApplicationImage is an image that is used by a container of a component of
+// an Application, in each of the instances.
+type ApplicationImage struct {
+	Application string    `json:"application"`
+	Component   string    `json:"component,omitempty"`
+	Container   string    `json:"container"`
+	Init        bool      `json:"init,omitempty"`
+	Image       string    `json:"image"`
+	Reference   Reference `json:"reference"`
+	Pinning     string    `json:"pinning"`
+	Instances   []string  `json:"instances,omitempty"`
+}
+
+// Registry is a registry that images are pulled from, with the number of
+// distinct images by how they are pinned.
+type Registry struct {
+	Name     string `json:"name"`
+	Images   int    `json:"images"`
+	ByDigest int    `json:"byDigest"`
+	ByTag    int    `json:"byTag"`
+	Unpinned int    `json:"unpinned"`
+}
+
+// SharedImage is an image that is used by more than one Application.
+type SharedImage struct {
+	Image        string   `json:"image"`
+	Applications []string `json:"applications"`
+}
+
+// NewReport returns the Report of the images in the usages.
+//
+// Images are compared by their parsed references, so nginx:1.25 and
+// docker.io/library/nginx:1.25 are the same image.
+func NewReport(usages []Usage) Report {
+	type key struct {
+		application, component, container, image string
+		init                                     bool
+	}
+	appImages := map[key]*ApplicationImage{}
+	registries := map[string]map[string]Reference{}
+	imageApps := map[string]map[string]bool{}
+
+	for _, u := range usages {
+		ref := Parse(u.Image)
+		k := key{application: u.Application, component: u.Component, container: u.Container, image: u.Image, init: u.Init}
+		img, ok := appImages[k]
+		if !ok {
+			img = &ApplicationImage{
+				Application: u.Application,
+				Component:   u.Component,
+				Container:   u.Container,
+				Init:        u.Init,
+				Image:       u.Image,
+				Reference:   ref,
+				Pinning:     ref.Pinning(),
+			}
+			appImages[k] = img
+		}
+		if u.Instance != "" && !slices.Contains(img.Instances, u.Instance) {
+			img.Instances = append(img.Instances, u.Instance)
+		}
+
+		if registries[ref.Registry] == nil {
+			registries[ref.Registry] = map[string]Reference{}
+		}
+		registries[ref.Registry][ref.String()] = ref
+		if imageApps[ref.String()] == nil {
+			imageApps[ref.String()] = map[string]bool{}
+		}
+		imageApps[ref.String()][u.Application] = true
+	}
+
+	r := Report{Images: []ApplicationImage{}, Registries: []Registry{}}
+	for _, img := range appImages {
+		sort.Strings(img.Instances)
+		r.Images = append(r.Images, *img)
+	}
+	slices.SortFunc(r.Images, compareImages)
+
+	names := maps.Keys(registries)
+	sort.Strings(names)
+	for _, name := range names {
+		reg := Registry{Name: name, Images: len(registries[name])}
+		for _, ref := range registries[name] {
+			switch ref.Pinning() {
+			case PinnedByDigest:
+				reg.ByDigest++
+			case PinnedByTag:
+				reg.ByTag++
+			default:
+				reg.Unpinned++
+			}
+		}
+		r.Registries = append(r.Registries, reg)
+	}
+
+	images := maps.Keys(imageApps)
+	sort.Strings(images)
+	for _, image := range images {
+		if len(imageApps[image]) > 1 {
+			apps := maps.Keys(imageApps[image])
+			sort.Strings(apps)
+			r.Shared = append(r.Shared, SharedImage{Image: image, Applications: apps})
+		}
+	}
+	return r
+}
+
+// compareImages orders images by application, component, container and
+// image, with the containers before the init containers.
+func compareImages(a, b ApplicationImage) int {
+	if c := cmp.Compare(a.Application, b.Application); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Component, b.Component); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Container, b.Container); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Image, b.Image); c != 0 {
+		return c
+	}
+	switch {
+	case a.Init == b.Init:
+		return 0
+	case b.Init:
+		return -1
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,67 @@
+package images
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewReport(t *testing.T) {
+	usages := []Usage{
+		{Application: "cart", Component: "backend", Instance: "cart-prod", Workload: "Deployment/prod/cart", Container: "cart", Image: "ghcr.io/sockshop/cart:v1.2.0"},
+		{Application: "cart", Component: "backend", Instance: "cart-dev", Workload: "Deployment/dev/cart", Container: "cart", Image: "ghcr.io/sockshop/cart:v1.2.0"},
+		{Application: "cart", Component: "backend", Instance: "cart-dev", Workload: "Deployment/dev/cart", Container: "proxy", Image: "envoyproxy/envoy:v1.29.0"},
+		{Application: "cart", Component: "backend", Instance: "cart-dev", Workload: "Deployment/dev/cart", Container: "migrate", Init: true, Image: "ghcr.io/sockshop/cart@sha256:4a5b6c"},
+		{Application: "orders", Instance: "orders", Workload: "Deployment/prod/orders", Container: "proxy", Image: "docker.io/envoyproxy/envoy:v1.29.0"},
+		{Application: "orders", Instance: "orders", Workload: "Deployment/prod/orders", Container: "orders", Image: "redis"},
+	}
+
+	r := NewReport(usages)
+
+	want := Report{
+		Images: []ApplicationImage{
+			{
+				Application: "cart", Component: "backend", Container: "cart", Image: "ghcr.io/sockshop/cart:v1.2.0",
+				Reference: Reference{Registry: "ghcr.io", Repository: "sockshop/cart", Tag: "v1.2.0"},
+				Pinning:   PinnedByTag, Instances: []string{"cart-dev", "cart-prod"},
+			},
+			{
+				Application: "cart", Component: "backend", Container: "migrate", Init: true, Image: "ghcr.io/sockshop/cart@sha256:4a5b6c",
+				Reference: Reference{Registry: "ghcr.io", Repository: "sockshop/cart", Digest: "sha256:4a5b6c"},
+				Pinning:   PinnedByDigest, Instances: []string{"cart-dev"},
+			},
+			{
+				Application: "cart", Component: "backend", Container: "proxy", Image: "envoyproxy/envoy:v1.29.0",
+				Reference: Reference{Registry: "docker.io", Repository: "envoyproxy/envoy", Tag: "v1.29.0"},
+				Pinning:   PinnedByTag, Instances: []string{"cart-dev"},
+			},
+			{
+				Application: "orders", Container: "orders", Image: "redis",
+				Reference: Reference{Registry: "docker.io", Repository: "library/redis"},
+				Pinning:   Unpinned, Instances: []string{"orders"},
+			},
+			{
+				Application: "orders", Container: "proxy", Image: "docker.io/envoyproxy/envoy:v1.29.0",
+				Reference: Reference{Registry: "docker.io", Repository: "envoyproxy/envoy", Tag: "v1.29.0"},
+				Pinning:   PinnedByTag, Instances: []string{"orders"},
+			},
+		},
+		Registries: []Registry{
+			{Name: "docker.io", Images: 2, ByTag: 1, Unpinned: 1},
+			{Name: "ghcr.io", Images: 2, ByDigest: 1, ByTag: 1},
+		},
+		Shared: []SharedImage{
+			{Image: "docker.io/envoyproxy/envoy:v1.29.0", Applications: []string{"cart", "orders"}},
+		},
+	}
+	if diff := cmp.Diff(want, r); diff != "" {
+		t.Fatalf("failed to create report:\n%s", diff)
+	}
+}
+
+func TestNewReport_without_usages(t *testing.T) {
+	want := Report{Images: []ApplicationImage{}, Registries: []Registry{}}
+	if diff := cmp.Diff(want, NewReport(nil)); diff != "" {
+		t.Fatalf("failed to create report:\n%s", diff)
+	}
+}
@@ -0,0 +1,241 @@
+package inventory
+
+import (
+	"slices"
+	"sort"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"github.com/gitops-tools/pkg/sets"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+// Changes is the set of differences between two Inventories.
+type Changes struct {
+	AddedApplications   []string            `json:"addedApplications,omitempty"`
+	RemovedApplications []string            `json:"removedApplications,omitempty"`
+	Applications        []ApplicationChange `json:"applications,omitempty"`
+	AddedPipelines      []string            `json:"addedPipelines,omitempty"`
+	RemovedPipelines    []string            `json:"removedPipelines,omitempty"`
+	Pipelines           []PipelineChange    `json:"pipelines,omitempty"`
+	Repositories        []RepositoryChange  `json:"repositories,omitempty"`
+}
+
+// Empty returns true if there are no differences.
+func (c Changes) Empty() bool {
+	return len(c.AddedApplications) == 0 && len(c.RemovedApplications) == 0 &&
+		len(c.Applications) == 0 && len(c.AddedPipelines) == 0 &&
+		len(c.RemovedPipelines) == 0 && len(c.Pipelines) == 0 &&
+		len(c.Repositories) == 0
+}
+
+// ApplicationChange records the differences in an Application that is present
+// in both Inventories.
+type ApplicationChange struct {
+	Name                  string   `json:"name"`
+	AddedComponents       []string `json:"addedComponents,omitempty"`
+	RemovedComponents     []string `json:"removedComponents,omitempty"`
+	AddedInstances        []string `json:"addedInstances,omitempty"`
+	RemovedInstances      []string `json:"removedInstances,omitempty"`
+	AddedParents          []string `json:"addedParents,omitempty"`
+	RemovedParents        []string `json:"removedParents,omitempty"`
+	AddedKustomizations   []string `json:"addedKustomizations,omitempty"`
+	RemovedKustomizations []string `json:"removedKustomizations,omitempty"`
+}
+
+func (c ApplicationChange) empty() bool {
+	return len(c.AddedComponents) == 0 && len(c.RemovedComponents) == 0 &&
+		len(c.AddedInstances) == 0 && len(c.RemovedInstances) == 0 &&
+		len(c.AddedParents) == 0 && len(c.RemovedParents) == 0 &&
+		len(c.AddedKustomizations) == 0 && len(c.RemovedKustomizations) == 0
+}
+
+// PipelineChange records a change to the environments of a Pipeline that is
+// present in both Inventories.
+type PipelineChange struct {
+	Name            string   `json:"name"`
+	OldEnvironments []string `json:"oldEnvironments"`
+	NewEnvironments []string `json:"newEnvironments"`
+	// Reordered is true if the environments are the same but in a different
+	// order.
+	Reordered bool `json:"reordered"`
+}
+
+// RepositoryChange records a change to the ref that a GitRepository tracks.
+//
+// If the GitRepository was added there is no OldRef, and if it was removed
+// there is no NewRef.
+type RepositoryChange struct {
+	types.NamespacedName
+	URL    string                     `json:"url"`
+	OldRef *sourcev1.GitRepositoryRef `json:"oldRef,omitempty"`
+	NewRef *sourcev1.GitRepositoryRef `json:"newRef,omitempty"`
+}
+
+// Diff compares two Inventories and returns the changes needed to go from the
+// old to the new Inventory.
+func Diff(old, new Inventory) Changes {
+	var c Changes
+
+	oldApps := applicationsByName(old.Applications)
+	newApps := applicationsByName(new.Applications)
+	c.AddedApplications, c.RemovedApplications = keysDiff(oldApps, newApps)
+	for _, name := range sortedKeys(newApps) {
+		oldApp, ok := oldApps[name]
+		if !ok {
+			continue
+		}
+		if change := diffApplication(oldApp, newApps[name]); !change.empty() {
+			c.Applications = append(c.Applications, change)
+		}
+	}
+
+	oldPipelines := pipelinesByName(old.Pipelines)
+	newPipelines := pipelinesByName(new.Pipelines)
+	c.AddedPipelines, c.RemovedPipelines = keysDiff(oldPipelines, newPipelines)
+	for _, name := range sortedKeys(newPipelines) {
+		oldPipeline, ok := oldPipelines[name]
+		if !ok {
+			continue
+		}
+		newPipeline := newPipelines[name]
+		if slices.Equal(oldPipeline.Environments, newPipeline.Environments) {
+			continue
+		}
+		added, removed := stringsDiff(oldPipeline.Environments, newPipeline.Environments)
+		c.Pipelines = append(c.Pipelines, PipelineChange{
+			Name:            name,
+			OldEnvironments: oldPipeline.Environments,
+			NewEnvironments: newPipeline.Environments,
+			Reordered:       len(added) == 0 && len(removed) == 0,
+		})
+	}
+
+	c.Repositories = diffRepositories(old, new)
+
+	return c
+}
+
+func diffApplication(old, new applications.Application) ApplicationChange {
+	c := ApplicationChange{Name: new.Name}
+	c.AddedComponents, c.RemovedComponents = stringsDiff(old.Components, new.Components)
+	c.AddedInstances, c.RemovedInstances = stringsDiff(old.Instances, new.Instances)
+	c.AddedParents, c.RemovedParents = stringsDiff(parentNames(old), parentNames(new))
+	c.AddedKustomizations, c.RemovedKustomizations = stringsDiff(
+		namespacedNames(old.Kustomizations), namespacedNames(new.Kustomizations))
+
+	return c
+}
+
+type trackedRef struct {
+	url string
+	ref sourcev1.GitRepositoryRef
+}
+
+func diffRepositories(old, new Inventory) []RepositoryChange {
+	oldRefs := repositoryRefs(old)
+	newRefs := repositoryRefs(new)
+
+	var res []RepositoryChange
+	// The refs are copied as the loop variables are shared between
+	// iterations.
+	for nn, n := range newRefs {
+		newRef := n.ref
+		o, ok := oldRefs[nn]
+		switch {
+		case !ok:
+			res = append(res, RepositoryChange{NamespacedName: nn, URL: n.url, NewRef: &newRef})
+		case o != n:
+			oldRef := o.ref
+			res = append(res, RepositoryChange{NamespacedName: nn, URL: n.url, OldRef: &oldRef, NewRef: &newRef})
+		}
+	}
+	for nn, o := range oldRefs {
+		if _, ok := newRefs[nn]; !ok {
+			oldRef := o.ref
+			res = append(res, RepositoryChange{NamespacedName: nn, URL: o.url, OldRef: &oldRef})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].String() < res[j].String() })
+
+	return res
+}
+
+func repositoryRefs(inv Inventory) map[types.NamespacedName]trackedRef {
+	res := map[types.NamespacedName]trackedRef{}
+	for _, r := range inv.Repositories {
+		for _, ref := range r.Refs {
+			res[ref.NamespacedName] = trackedRef{url: r.URL, ref: ref.Ref}
+		}
+	}
+	return res
+}
+
+func applicationsByName(apps []applications.Application) map[string]applications.Application {
+	res := map[string]applications.Application{}
+	for _, v := range apps {
+		res[v.Name] = v
+	}
+	return res
+}
+
+func pipelinesByName(pls []pipelines.Pipeline) map[string]pipelines.Pipeline {
+	res := map[string]pipelines.Pipeline{}
+	for _, v := range pls {
+		res[v.Name] = v
+	}
+	return res
+}
+
+func parentNames(app applications.Application) []string {
+	var res []string
+	for _, p := range app.Parents {
+		res = append(res, p.Name)
+	}
+	return res
+}
+
+func namespacedNames(nns []types.NamespacedName) []string {
+	var res []string
+	for _, nn := range nns {
+		res = append(res, nn.String())
+	}
+	return res
+}
+
+// stringsDiff returns the sorted items that are only in new, and only in old.
+func stringsDiff(old, new []string) (added, removed []string) {
+	oldSet := sets.New(nonEmpty(old)...)
+	newSet := sets.New(nonEmpty(new)...)
+
+	return sortedStrings(newSet.Difference(oldSet)), sortedStrings(oldSet.Difference(newSet))
+}
+
+func keysDiff[V any](old, new map[string]V) (added, removed []string) {
+	return stringsDiff(sortedKeys(old), sortedKeys(new))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func sortedStrings(s sets.Set[string]) []string {
+	return s.SortedList(func(x, y string) bool { return x < y })
+}
+
+func nonEmpty(s []string) []string {
+	var res []string
+	for _, v := range s {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
@@ -0,0 +1,289 @@
+package inventory
+
+import (
+	"testing"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+func TestDiff(t *testing.T) {
+	diffTests := []struct {
+		name string
+		old  Inventory
+		new  Inventory
+		want Changes
+	}{
+		{
+			name: "no changes",
+			old:  makeInventory(),
+			new:  makeInventory(),
+			want: Changes{},
+		},
+		{
+			name: "added and removed applications",
+			old:  makeInventory(),
+			new: makeInventory(func(inv *Inventory) {
+				inv.Applications[0].Name = "orders"
+			}),
+			want: Changes{
+				AddedApplications:   []string{"orders"},
+				RemovedApplications: []string{"frontend"},
+			},
+		},
+		{
+			name: "changed components, instances and parents",
+			old:  makeInventory(),
+			new: makeInventory(func(inv *Inventory) {
+				inv.Applications[0].Components = []string{"web", "cache"}
+				inv.Applications[0].Instances = []string{"frontend-production"}
+				inv.Applications[0].Parents = []applications.Application{{Name: "shop"}}
+			}),
+			want: Changes{
+				Applications: []ApplicationChange{
+					{
+						Name:              "frontend",
+						AddedComponents:   []string{"cache"},
+						RemovedComponents: []string{"database"},
+						AddedInstances:    []string{"frontend-production"},
+						RemovedInstances:  []string{"frontend-staging"},
+						AddedParents:      []string{"shop"},
+						RemovedParents:    []string{"billing-system"},
+					},
+				},
+			},
+		},
+		{
+			name: "changed kustomizations",
+			old:  makeInventory(),
+			new: makeInventory(func(inv *Inventory) {
+				inv.Applications[0].Kustomizations = []types.NamespacedName{{Name: "repo-production", Namespace: "flux-system"}}
+			}),
+			want: Changes{
+				Applications: []ApplicationChange{
+					{
+						Name:                  "frontend",
+						AddedKustomizations:   []string{"flux-system/repo-production"},
+						RemovedKustomizations: []string{"flux-system/repo-main"},
+					},
+				},
+			},
+		},
+		{
+			name: "reordered pipeline",
+			old:  makeInventory(),
+			new: makeInventory(func(inv *Inventory) {
+				inv.Pipelines[0].Environments = []string{"production", "staging"}
+			}),
+			want: Changes{
+				Pipelines: []PipelineChange{
+					{
+						Name:            "billing-pipeline",
+						OldEnvironments: []string{"staging", "production"},
+						NewEnvironments: []string{"production", "staging"},
+						Reordered:       true,
+					},
+				},
+			},
+		},
+		{
+			name: "changed pipeline environments",
+			old:  makeInventory(),
+			new: makeInventory(func(inv *Inventory) {
+				inv.Pipelines[0].Environments = []string{"dev", "staging", "production"}
+			}),
+			want: Changes{
+				Pipelines: []PipelineChange{
+					{
+						Name:            "billing-pipeline",
+						OldEnvironments: []string{"staging", "production"},
+						NewEnvironments: []string{"dev", "staging", "production"},
+					},
+				},
+			},
+		},
+		{
+			name: "added pipeline",
+			old:  makeInventory(),
+			new: makeInventory(func(inv *Inventory) {
+				inv.Pipelines = append(inv.Pipelines, pipelines.Pipeline{Name: "web-pipeline"})
+			}),
+			want: Changes{
+				AddedPipelines: []string{"web-pipeline"},
+			},
+		},
+		{
+			name: "changed repository ref",
+			old:  makeInventory(),
+			new: makeInventory(func(inv *Inventory) {
+				inv.Repositories[0].Refs[0].Ref = sourcev1.GitRepositoryRef{Tag: "v1.0.0"}
+			}),
+			want: Changes{
+				Repositories: []RepositoryChange{
+					{
+						NamespacedName: types.NamespacedName{Name: "repo", Namespace: "flux-system"},
+						URL:            "https://github.com/example/repo.git",
+						OldRef:         &sourcev1.GitRepositoryRef{Branch: "main"},
+						NewRef:         &sourcev1.GitRepositoryRef{Tag: "v1.0.0"},
+					},
+				},
+			},
+		},
+		{
+			name: "removed repository",
+			old:  makeInventory(),
+			new: makeInventory(func(inv *Inventory) {
+				inv.Repositories = nil
+			}),
+			want: Changes{
+				Repositories: []RepositoryChange{
+					{
+						NamespacedName: types.NamespacedName{Name: "repo", Namespace: "flux-system"},
+						URL:            "https://github.com/example/repo.git",
+						OldRef:         &sourcev1.GitRepositoryRef{Branch: "main"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range diffTests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Diff(tt.old, tt.new)
+			if diff := cmp.Diff(tt.want, changes); diff != "" {
+				t.Fatalf("failed to diff inventories:\n%s", diff)
+			}
+			if changes.Empty() != cmp.Equal(tt.want, Changes{}) {
+				t.Fatalf("Empty() got %v", changes.Empty())
+			}
+		})
+	}
+}
+
+func TestDiff_multiple_repositories(t *testing.T) {
+	cart := types.NamespacedName{Name: "cart", Namespace: "flux-system"}
+	orders := types.NamespacedName{Name: "orders", Namespace: "flux-system"}
+	repositories := func(refs ...sourcev1.GitRepositoryRef) []flux.Repository {
+		return []flux.Repository{
+			{URL: "https://github.com/example/cart.git", Refs: []flux.RepositoryRef{{NamespacedName: cart, Ref: refs[0]}}},
+			{URL: "https://github.com/example/orders.git", Refs: []flux.RepositoryRef{{NamespacedName: orders, Ref: refs[1]}}},
+		}
+	}
+
+	diffTests := []struct {
+		name string
+		old  []flux.Repository
+		new  []flux.Repository
+		want []RepositoryChange
+	}{
+		{
+			name: "added repositories",
+			new:  repositories(sourcev1.GitRepositoryRef{Branch: "main"}, sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+			want: []RepositoryChange{
+				{NamespacedName: cart, URL: "https://github.com/example/cart.git", NewRef: &sourcev1.GitRepositoryRef{Branch: "main"}},
+				{NamespacedName: orders, URL: "https://github.com/example/orders.git", NewRef: &sourcev1.GitRepositoryRef{Tag: "v1.0.0"}},
+			},
+		},
+		{
+			name: "changed repositories",
+			old:  repositories(sourcev1.GitRepositoryRef{Branch: "main"}, sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+			new:  repositories(sourcev1.GitRepositoryRef{Tag: "v2.0.0"}, sourcev1.GitRepositoryRef{SemVer: ">=1.0.0"}),
+			want: []RepositoryChange{
+				{
+					NamespacedName: cart, URL: "https://github.com/example/cart.git",
+					OldRef: &sourcev1.GitRepositoryRef{Branch: "main"}, NewRef: &sourcev1.GitRepositoryRef{Tag: "v2.0.0"},
+				},
+				{
+					NamespacedName: orders, URL: "https://github.com/example/orders.git",
+					OldRef: &sourcev1.GitRepositoryRef{Tag: "v1.0.0"}, NewRef: &sourcev1.GitRepositoryRef{SemVer: ">=1.0.0"},
+				},
+			},
+		},
+		{
+			name: "removed repositories",
+			old:  repositories(sourcev1.GitRepositoryRef{Branch: "main"}, sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+			want: []RepositoryChange{
+				{NamespacedName: cart, URL: "https://github.com/example/cart.git", OldRef: &sourcev1.GitRepositoryRef{Branch: "main"}},
+				{NamespacedName: orders, URL: "https://github.com/example/orders.git", OldRef: &sourcev1.GitRepositoryRef{Tag: "v1.0.0"}},
+			},
+		},
+	}
+
+	for _, tt := range diffTests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Diff(Inventory{Repositories: tt.old}, Inventory{Repositories: tt.new})
+			if diff := cmp.Diff(tt.want, changes.Repositories); diff != "" {
+				t.Fatalf("failed to diff repositories:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiff_branching_pipeline(t *testing.T) {
+	stage := func(env, after string) metav1.PartialObjectMetadata {
+		return metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+			pipelines.PipelineNameLabel:             "billing-pipeline",
+			pipelines.PipelineEnvironmentLabel:      env,
+			pipelines.PipelineEnvironmentAfterLabel: after,
+		}}}
+	}
+	scan := func(stages ...metav1.PartialObjectMetadata) Inventory {
+		t.Helper()
+		p := pipelines.NewParser()
+		p.AddMetadata(stages)
+		discovered, err := p.Pipelines()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Inventory{Pipelines: discovered}
+	}
+
+	// The staging environments both follow dev, so scans of the same
+	// pipeline must order them the same way.
+	old := scan(stage("dev", ""), stage("staging-a", "dev"), stage("staging-b", "dev"), stage("production", "staging-b"))
+	for i := 0; i < 100; i++ {
+		new := scan(stage("production", "staging-b"), stage("staging-b", "dev"), stage("staging-a", "dev"), stage("dev", ""))
+		if changes := Diff(old, new); !changes.Empty() {
+			t.Fatalf("got changes diffing an unchanged pipeline: %#v", changes.Pipelines)
+		}
+	}
+}
+
+func makeInventory(opts ...func(*Inventory)) Inventory {
+	inv := Inventory{
+		Applications: []applications.Application{
+			{
+				Name:           "frontend",
+				Instances:      []string{"frontend-staging"},
+				Components:     []string{"database", "web"},
+				Parents:        []applications.Application{{Name: "billing-system"}},
+				Kustomizations: []types.NamespacedName{{Name: "repo-main", Namespace: "flux-system"}},
+			},
+			{Name: "billing-system"},
+		},
+		Pipelines: []pipelines.Pipeline{
+			{Name: "billing-pipeline", Environments: []string{"staging", "production"}},
+		},
+		Repositories: []flux.Repository{
+			{
+				URL: "https://github.com/example/repo.git",
+				Refs: []flux.RepositoryRef{
+					{
+						NamespacedName: types.NamespacedName{Name: "repo", Namespace: "flux-system"},
+						Ref:            sourcev1.GitRepositoryRef{Branch: "main"},
+					},
+				},
+			},
+		},
+	}
+	for _, opt := range opts {
+		opt(&inv)
+	}
+	return inv
+}
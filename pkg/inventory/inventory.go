@@ -0,0 +1,100 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/argocd"
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+// Inventory is the combined result of scanning for Applications, Pipelines
+// and Repositories.
+type Inventory struct {
+	Applications []applications.Application `json:"applications"`
+	Pipelines    []pipelines.Pipeline       `json:"pipelines"`
+	Repositories []flux.Repository          `json:"repositories"`
+
+	// ArgoApplications and ArgoApplicationSets are only discovered in
+	// clusters with Argo CD installed.
+	ArgoApplications    []argocd.Application    `json:"argoApplications,omitempty"`
+	ArgoApplicationSets []argocd.ApplicationSet `json:"argoApplicationSets,omitempty"`
+
+	// Coverage is only recorded when some of the resources could not be
+	// scanned.
+	Coverage *Coverage `json:"coverage,omitempty"`
+}
+
+// Coverage records the lists of resources that could not be scanned, so the
+// Inventory is missing anything that they would have discovered.
+type Coverage struct {
+	// Skipped are the lists that were not permitted.
+	Skipped []Gap `json:"skipped,omitempty"`
+	// Failed are the lists that were made and failed.
+	Failed []Gap `json:"failed,omitempty"`
+}
+
+// Gap is a kind of resource that could not be listed in a namespace, or in
+// all namespaces if the namespace is empty.
+type Gap struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+func (g Gap) String() string {
+	if g.Namespace == "" {
+		return g.Kind
+	}
+	return g.Kind + " in " + g.Namespace
+}
+
+// Write encodes the Inventory as JSON.
+func Write(w io.Writer, inv Inventory) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	if err := e.Encode(inv); err != nil {
+		return fmt.Errorf("failed to encode inventory: %w", err)
+	}
+	return nil
+}
+
+// WriteResult encodes the result of scanning part of the Inventory as JSON,
+// an object with the result in the named field, and the Coverage of the scan
+// in the coverage field when the scan was incomplete.
+func WriteResult(w io.Writer, name string, result any, c *Coverage) error {
+	out := map[string]any{name: result}
+	if c != nil {
+		out["coverage"] = c
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	if err := e.Encode(out); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return nil
+}
+
+// Read decodes a JSON encoded Inventory.
+func Read(r io.Reader) (*Inventory, error) {
+	var inv Inventory
+	if err := json.NewDecoder(r).Decode(&inv); err != nil {
+		return nil, fmt.Errorf("failed to decode inventory: %w", err)
+	}
+	return &inv, nil
+}
+
+// ReadFile decodes a JSON encoded Inventory from a file.
+func ReadFile(filename string) (*Inventory, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inventory: %w", err)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
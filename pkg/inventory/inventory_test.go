@@ -0,0 +1,93 @@
+package inventory
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	inv := makeInventory()
+
+	var b bytes.Buffer
+	test.AssertNoError(t, Write(&b, inv))
+
+	read, err := Read(&b)
+	test.AssertNoError(t, err)
+
+	if diff := cmp.Diff(inv, *read); diff != "" {
+		t.Fatalf("failed to read inventory:\n%s", diff)
+	}
+}
+
+func TestWriteResult(t *testing.T) {
+	resultTests := []struct {
+		name     string
+		coverage *Coverage
+		want     string
+	}{
+		{
+			name: "complete scan",
+			want: `{
+  "pipelines": [
+    {
+      "name": "billing-pipeline",
+      "environments": [
+        "staging",
+        "production"
+      ]
+    }
+  ]
+}
+`,
+		},
+		{
+			name: "incomplete scan",
+			coverage: &Coverage{
+				Skipped: []Gap{{Kind: "Deployment.apps", Namespace: "billing", Reason: "access denied"}},
+			},
+			want: `{
+  "coverage": {
+    "skipped": [
+      {
+        "kind": "Deployment.apps",
+        "namespace": "billing",
+        "reason": "access denied"
+      }
+    ]
+  },
+  "pipelines": [
+    {
+      "name": "billing-pipeline",
+      "environments": [
+        "staging",
+        "production"
+      ]
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tt := range resultTests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			test.AssertNoError(t, WriteResult(&b, "pipelines", makeInventory().Pipelines, tt.coverage))
+
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Fatalf("failed to write result:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRead_invalid(t *testing.T) {
+	_, err := Read(strings.NewReader("not json"))
+
+	test.AssertErrorMatch(t, "failed to decode inventory", err)
+}
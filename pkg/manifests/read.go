@@ -0,0 +1,166 @@
+package manifests
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Read decodes the Kubernetes objects in a stream of YAML or JSON documents,
+// for example the output from `kustomize build`.
+//
+// Documents that are empty, or that don't have a kind, are skipped and List
+// documents, for example the output from `kubectl get -o yaml`, are expanded
+// into their items.
+//
+// Items in typed lists e.g. a DeploymentList from `kubectl cluster-info dump`
+// are often written without a kind, these take the kind from the list.
+func Read(r io.Reader) ([]unstructured.Unstructured, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	var res []unstructured.Unstructured
+	for {
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode manifests: %w", err)
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		u := unstructured.Unstructured{Object: doc}
+		if u.GetKind() == "" {
+			continue
+		}
+		if u.IsList() {
+			itemKind := strings.TrimSuffix(u.GetKind(), "List")
+			err := u.EachListItem(func(obj runtime.Object) error {
+				item := obj.(*unstructured.Unstructured)
+				if item.GetKind() == "" && itemKind != "" {
+					item.SetAPIVersion(u.GetAPIVersion())
+					item.SetKind(itemKind)
+				}
+				res = append(res, *item)
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode manifests: %w", err)
+			}
+			continue
+		}
+		res = append(res, u)
+	}
+
+	return res, nil
+}
+
+// ReadArchive reads the Kubernetes objects in all the YAML and JSON files in a
+// gzipped tar archive.
+func ReadArchive(r io.Reader) ([]unstructured.Unstructured, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	var res []unstructured.Unstructured
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !isManifestFile(hdr.Name) {
+			continue
+		}
+		objs, err := Read(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+		res = append(res, objs...)
+	}
+
+	return res, nil
+}
+
+// ReadPath reads the Kubernetes objects in a file, or in all the YAML and JSON
+// files within a directory.
+//
+// Gzipped tar archives, for example of a `kubectl cluster-info dump`
+// directory, are read with ReadArchive and the other files in a
+// `kubectl cluster-info dump` directory, such as logs, are ignored.
+func ReadPath(path string) ([]unstructured.Unstructured, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifests: %w", err)
+	}
+	if !info.IsDir() {
+		return readFile(path)
+	}
+
+	var res []unstructured.Unstructured
+	err = filepath.WalkDir(path, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !(isManifestFile(filename) || isArchiveFile(filename)) {
+			return nil
+		}
+		objs, err := readFile(filename)
+		if err != nil {
+			return err
+		}
+		res = append(res, objs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func readFile(filename string) ([]unstructured.Unstructured, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifests: %w", err)
+	}
+	defer f.Close()
+
+	read := Read
+	if isArchiveFile(filename) {
+		read = ReadArchive
+	}
+	objs, err := read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return objs, nil
+}
+
+func isManifestFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+func isArchiveFile(filename string) bool {
+	filename = strings.ToLower(filename)
+	return strings.HasSuffix(filename, ".tar.gz") || strings.HasSuffix(filename, ".tgz")
+}
@@ -0,0 +1,178 @@
+package manifests
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+func TestRead(t *testing.T) {
+	objs, err := Read(strings.NewReader(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+---
+# Documents without a kind are skipped.
+data:
+  key: value
+---
+{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "second"}}
+`))
+	test.AssertNoError(t, err)
+
+	if diff := cmp.Diff([]string{"ConfigMap/first", "ConfigMap/second"}, objectNames(objs)); diff != "" {
+		t.Fatalf("failed to read manifests:\n%s", diff)
+	}
+}
+
+func TestRead_list(t *testing.T) {
+	objs, err := Read(strings.NewReader(`apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: cart
+    namespace: sockshop
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: cart
+    namespace: sockshop
+metadata:
+  resourceVersion: ""
+`))
+	test.AssertNoError(t, err)
+
+	if diff := cmp.Diff([]string{"Deployment/cart", "Service/cart"}, objectNames(objs)); diff != "" {
+		t.Fatalf("failed to read manifests:\n%s", diff)
+	}
+}
+
+func TestRead_invalid(t *testing.T) {
+	_, err := Read(strings.NewReader("- not\n- an object\n"))
+
+	test.AssertErrorMatch(t, "failed to decode manifests", err)
+}
+
+func TestReadPath_directory(t *testing.T) {
+	objs, err := ReadPath("testdata/app")
+	test.AssertNoError(t, err)
+
+	want := []string{"Deployment/frontend", "Service/frontend", "Kustomization/"}
+	if diff := cmp.Diff(want, objectNames(objs)); diff != "" {
+		t.Fatalf("failed to read manifests:\n%s", diff)
+	}
+}
+
+func TestReadPath_file(t *testing.T) {
+	objs, err := ReadPath("testdata/app/base/deployment.yaml")
+	test.AssertNoError(t, err)
+
+	want := []string{"Deployment/frontend", "Service/frontend"}
+	if diff := cmp.Diff(want, objectNames(objs)); diff != "" {
+		t.Fatalf("failed to read manifests:\n%s", diff)
+	}
+}
+
+func TestReadPath_cluster_info_dump(t *testing.T) {
+	objs, err := ReadPath("testdata/dump")
+	test.AssertNoError(t, err)
+
+	want := []string{"Node/kind-control-plane", "Deployment/cart"}
+	if diff := cmp.Diff(want, objectNames(objs)); diff != "" {
+		t.Fatalf("failed to read manifests:\n%s", diff)
+	}
+	if v := objs[1].GetAPIVersion(); v != "apps/v1" {
+		t.Fatalf("got apiVersion %q, want %q", v, "apps/v1")
+	}
+}
+
+func TestReadPath_archive(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dump.tar.gz")
+	test.AssertNoError(t, os.WriteFile(filename, makeArchive(t, map[string]string{
+		"dump/sockshop/deployments.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: cart\n",
+	}), 0o600))
+
+	objs, err := ReadPath(filepath.Dir(filename))
+	test.AssertNoError(t, err)
+
+	if diff := cmp.Diff([]string{"Deployment/cart"}, objectNames(objs)); diff != "" {
+		t.Fatalf("failed to read manifests:\n%s", diff)
+	}
+}
+
+func TestReadArchive(t *testing.T) {
+	archive := makeArchive(t, map[string]string{
+		"all.yaml":           "apiVersion: v1\nkind: List\nitems:\n- apiVersion: apps/v1\n  kind: Deployment\n  metadata:\n    name: cart\n",
+		"sockshop/logs.txt":  "not a manifest",
+		"sockshop/pods.json": `{"kind": "PodList", "apiVersion": "v1", "items": [{"metadata": {"name": "cart-5d8f7b9c4-x2x7k"}}]}`,
+	})
+
+	objs, err := ReadArchive(bytes.NewReader(archive))
+	test.AssertNoError(t, err)
+
+	want := []string{"Deployment/cart", "Pod/cart-5d8f7b9c4-x2x7k"}
+	if diff := cmp.Diff(want, objectNames(objs)); diff != "" {
+		t.Fatalf("failed to read manifests:\n%s", diff)
+	}
+}
+
+func TestReadArchive_invalid(t *testing.T) {
+	_, err := ReadArchive(strings.NewReader("not an archive"))
+
+	test.AssertErrorMatch(t, "failed to read archive", err)
+}
+
+func TestReadPath_missing(t *testing.T) {
+	_, err := ReadPath("testdata/missing")
+
+	test.AssertErrorMatch(t, "failed to read manifests", err)
+}
+
+// makeArchive returns a gzipped tar archive of the files, in name order.
+func makeArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		test.AssertNoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o600,
+			Size:     int64(len(files[name])),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(files[name]))
+		test.AssertNoError(t, err)
+	}
+	test.AssertNoError(t, tw.Close())
+	test.AssertNoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+func objectNames(objs []unstructured.Unstructured) []string {
+	var res []string
+	for _, obj := range objs {
+		res = append(res, obj.GetKind()+"/"+obj.GetName())
+	}
+	return res
+}
@@ -0,0 +1,149 @@
+package pipelines
+
+import (
+	"fmt"
+	"path"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+)
+
+// The promotion conventions that the stages of Pipelines are checked against.
+const (
+	// ConventionBranchAfterTag is violated when a stage tracks a branch after
+	// an earlier stage tracks a tag, semver range or commit, so the later
+	// stage gets changes before they are promoted.
+	ConventionBranchAfterTag = "branch-after-tag"
+
+	// ConventionDuplicateSource is violated when two stages track the same
+	// ref and path of a repository, so they are not promoted separately.
+	ConventionDuplicateSource = "duplicate-source"
+)
+
+// StageSource is the source of a Kustomization in a stage of a Pipeline.
+type StageSource struct {
+	Stage         string               `json:"stage"`
+	Kustomization types.NamespacedName `json:"kustomization"`
+	Path          string               `json:"path,omitempty"`
+
+	// Repository is nil if the source of the Kustomization is not one of the
+	// GitRepositories.
+	Repository *types.NamespacedName      `json:"repository,omitempty"`
+	URL        string                     `json:"url,omitempty"`
+	Ref        *sourcev1.GitRepositoryRef `json:"ref,omitempty"`
+}
+
+// ConventionViolation is a pair of stages of a Pipeline that violate a
+// promotion convention.
+type ConventionViolation struct {
+	Pipeline   string   `json:"pipeline"`
+	Convention string   `json:"convention"`
+	Stages     []string `json:"stages"`
+	Message    string   `json:"message"`
+}
+
+// StageSources returns the sources of the Kustomizations in each of the
+// stages, in the order of the stages.
+func StageSources(stages []Stage, repositories []sourcev1.GitRepository) []StageSource {
+	var res []StageSource
+	for _, stage := range stages {
+		for _, k := range stage.Kustomizations {
+			src := StageSource{
+				Stage:         stage.Name,
+				Kustomization: types.NamespacedName{Name: k.Name, Namespace: k.Namespace},
+				Path:          k.Spec.Path,
+			}
+			if repo := SourceRepository(k, repositories); repo != nil {
+				src.Repository = &types.NamespacedName{Name: repo.Name, Namespace: repo.Namespace}
+				src.URL = repo.Spec.URL
+				src.Ref = &sourcev1.GitRepositoryRef{}
+				if repo.Spec.Reference != nil {
+					src.Ref = repo.Spec.Reference
+				}
+			}
+			res = append(res, src)
+		}
+	}
+	return res
+}
+
+// CheckConventions checks the sources of the stages of a Pipeline against the
+// promotion conventions, comparing each stage with each of the stages that it
+// follows, stages in other branches of the Pipeline are not compared.
+//
+// Each pair of stages violates each convention at most once.
+func CheckConventions(pl Pipeline, sources []StageSource) []ConventionViolation {
+
+	var res []ConventionViolation
+	seen := map[[3]string]bool{}
+	add := func(convention string, earlier, later StageSource, message string) {
+		key := [3]string{convention, earlier.Stage, later.Stage}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		res = append(res, ConventionViolation{
+			Pipeline:   pl.Name,
+			Convention: convention,
+			Stages:     []string{earlier.Stage, later.Stage},
+			Message:    message,
+		})
+	}
+
+	for _, earlier := range sources {
+		for _, later := range sources {
+			if earlier.Ref == nil || later.Ref == nil || !follows(pl, later.Stage, earlier.Stage) {
+				continue
+			}
+			if pinned(*earlier.Ref) && !pinned(*later.Ref) {
+				add(ConventionBranchAfterTag, earlier, later, fmt.Sprintf("%s tracks %s after %s tracks %s",
+					later.Stage, describeRef(*later.Ref), earlier.Stage, describeRef(*earlier.Ref)))
+			}
+			if earlier.URL == later.URL && describeRef(*earlier.Ref) == describeRef(*later.Ref) && cleanPath(earlier.Path) == cleanPath(later.Path) {
+				add(ConventionDuplicateSource, earlier, later, fmt.Sprintf("%s and %s both track %s at %s in %s",
+					earlier.Stage, later.Stage, describeRef(*earlier.Ref), cleanPath(earlier.Path), earlier.URL))
+			}
+		}
+	}
+	return res
+}
+
+// follows returns true if the stage is promoted from the earlier stage,
+// directly or through other stages.
+func follows(pl Pipeline, stage, earlier string) bool {
+	// Each stage is visited at most once, in case the edges have a cycle.
+	for i := 0; i < len(pl.After); i++ {
+		after, ok := pl.After[stage]
+		if !ok {
+			return false
+		}
+		if after == earlier {
+			return true
+		}
+		stage = after
+	}
+	return false
+}
+
+// pinned returns true if the ref tracks a tag, semver range or commit rather
+// than a branch.
+func pinned(ref sourcev1.GitRepositoryRef) bool {
+	return ref.Commit != "" || ref.SemVer != "" || ref.Tag != ""
+}
+
+// describeRef describes the ref, GitRepositories without a ref track the
+// default branch.
+func describeRef(ref sourcev1.GitRepositoryRef) string {
+	if s := flux.DescribeRef(ref); s != "" {
+		return s
+	}
+	return "the default branch"
+}
+
+// cleanPath cleans the path of a Kustomization, which is relative to the
+// root of the source, so "", "." and "./" are all the root.
+func cleanPath(p string) string {
+	return "./" + path.Clean("/" + p)[1:]
+}
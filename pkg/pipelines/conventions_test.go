@@ -0,0 +1,186 @@
+package pipelines
+
+import (
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestStageSources(t *testing.T) {
+	repositories := []sourcev1.GitRepository{
+		makeGitRepository("sockshop", "https://github.com/example/sockshop", &sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+	}
+	dev := makeKustomization("sockshop-dev", "flux-system", nil)
+	dev.Spec.Path = "./dev"
+	dev.Spec.SourceRef = kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: "sockshop"}
+	prod := makeKustomization("sockshop-prod", "flux-system", nil)
+	prod.Spec.SourceRef = kustomizev1.CrossNamespaceSourceReference{Kind: "Bucket", Name: "sockshop"}
+
+	sources := StageSources([]Stage{
+		{Name: "dev", Kustomizations: []kustomizev1.Kustomization{dev}},
+		{Name: "prod", Kustomizations: []kustomizev1.Kustomization{prod}},
+	}, repositories)
+
+	want := []StageSource{
+		{
+			Stage:         "dev",
+			Kustomization: types.NamespacedName{Name: "sockshop-dev", Namespace: "flux-system"},
+			Path:          "./dev",
+			Repository:    &types.NamespacedName{Name: "sockshop", Namespace: "flux-system"},
+			URL:           "https://github.com/example/sockshop",
+			Ref:           &sourcev1.GitRepositoryRef{Tag: "v1.0.0"},
+		},
+		{
+			Stage:         "prod",
+			Kustomization: types.NamespacedName{Name: "sockshop-prod", Namespace: "flux-system"},
+		},
+	}
+	if diff := cmp.Diff(want, sources); diff != "" {
+		t.Fatalf("failed to find stage sources:\n%s", diff)
+	}
+}
+
+func TestCheckConventions(t *testing.T) {
+	pl := Pipeline{
+		Name:         "sockshop",
+		Environments: []string{"dev", "staging", "prod"},
+		After:        map[string]string{"staging": "dev", "prod": "staging"},
+	}
+	url := "https://github.com/example/sockshop"
+	source := func(stage, path string, ref sourcev1.GitRepositoryRef) StageSource {
+		return StageSource{Stage: stage, Path: path, URL: url, Ref: &ref}
+	}
+
+	conventionTests := []struct {
+		name    string
+		sources []StageSource
+		want    []ConventionViolation
+	}{
+		{
+			name: "promoted by tags",
+			sources: []StageSource{
+				source("dev", "./dev", sourcev1.GitRepositoryRef{Branch: "main"}),
+				source("staging", "./staging", sourcev1.GitRepositoryRef{SemVer: ">=1.0.0-rc"}),
+				source("prod", "./prod", sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+			},
+		},
+		{
+			name: "production tracks a branch after staging tracks a tag",
+			sources: []StageSource{
+				source("staging", "./staging", sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+				source("prod", "./prod", sourcev1.GitRepositoryRef{Branch: "main"}),
+			},
+			want: []ConventionViolation{
+				{
+					Pipeline: "sockshop", Convention: ConventionBranchAfterTag, Stages: []string{"staging", "prod"},
+					Message: "prod tracks branch main after staging tracks tag v1.0.0",
+				},
+			},
+		},
+		{
+			name: "production tracks the default branch",
+			sources: []StageSource{
+				source("staging", "./staging", sourcev1.GitRepositoryRef{Commit: "4a5b6c"}),
+				source("prod", "./prod", sourcev1.GitRepositoryRef{}),
+			},
+			want: []ConventionViolation{
+				{
+					Pipeline: "sockshop", Convention: ConventionBranchAfterTag, Stages: []string{"staging", "prod"},
+					Message: "prod tracks the default branch after staging tracks commit 4a5b6c",
+				},
+			},
+		},
+		{
+			name: "stages track the same ref and path",
+			sources: []StageSource{
+				source("dev", "./deploy", sourcev1.GitRepositoryRef{Branch: "main"}),
+				source("staging", "deploy/", sourcev1.GitRepositoryRef{Branch: "main"}),
+				source("prod", "./deploy", sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+			},
+			want: []ConventionViolation{
+				{
+					Pipeline: "sockshop", Convention: ConventionDuplicateSource, Stages: []string{"dev", "staging"},
+					Message: "dev and staging both track branch main at ./deploy in https://github.com/example/sockshop",
+				},
+			},
+		},
+		{
+			name: "multiple kustomizations in a stage",
+			sources: []StageSource{
+				source("staging", "./staging/cart", sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+				source("staging", "./staging/orders", sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+				source("prod", "./prod/cart", sourcev1.GitRepositoryRef{Branch: "main"}),
+				source("prod", "./prod/orders", sourcev1.GitRepositoryRef{Branch: "main"}),
+			},
+			want: []ConventionViolation{
+				{
+					Pipeline: "sockshop", Convention: ConventionBranchAfterTag, Stages: []string{"staging", "prod"},
+					Message: "prod tracks branch main after staging tracks tag v1.0.0",
+				},
+			},
+		},
+		{
+			name: "sources that are not GitRepositories",
+			sources: []StageSource{
+				source("staging", "./staging", sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+				{Stage: "prod", Path: "./prod"},
+			},
+		},
+	}
+
+	for _, tt := range conventionTests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := CheckConventions(pl, tt.sources)
+
+			if diff := cmp.Diff(tt.want, violations); diff != "" {
+				t.Fatalf("failed to check conventions:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckConventions_sibling_stages(t *testing.T) {
+	pl := Pipeline{
+		Name:         "sockshop",
+		Environments: []string{"dev", "staging-a", "staging-b", "prod"},
+		After:        map[string]string{"staging-a": "dev", "staging-b": "dev", "prod": "staging-b"},
+	}
+	url := "https://github.com/example/sockshop"
+	source := func(stage, path string, ref sourcev1.GitRepositoryRef) StageSource {
+		return StageSource{Stage: stage, Path: path, URL: url, Ref: &ref}
+	}
+
+	// staging-b tracks a branch after staging-a tracks a tag, but it is
+	// promoted from dev rather than from staging-a, so they are not compared.
+	violations := CheckConventions(pl, []StageSource{
+		source("dev", "./dev", sourcev1.GitRepositoryRef{Tag: "v1.0.0-rc.1"}),
+		source("staging-a", "./deploy", sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+		source("staging-b", "./deploy", sourcev1.GitRepositoryRef{Tag: "v1.0.0"}),
+		source("prod", "./prod", sourcev1.GitRepositoryRef{Branch: "main"}),
+	})
+
+	want := []ConventionViolation{
+		{
+			Pipeline: "sockshop", Convention: ConventionBranchAfterTag, Stages: []string{"dev", "prod"},
+			Message: "prod tracks branch main after dev tracks tag v1.0.0-rc.1",
+		},
+		{
+			Pipeline: "sockshop", Convention: ConventionBranchAfterTag, Stages: []string{"staging-b", "prod"},
+			Message: "prod tracks branch main after staging-b tracks tag v1.0.0",
+		},
+	}
+	if diff := cmp.Diff(want, violations); diff != "" {
+		t.Fatalf("failed to check conventions:\n%s", diff)
+	}
+}
+
+func makeGitRepository(name, url string, ref *sourcev1.GitRepositoryRef) sourcev1.GitRepository {
+	return sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "flux-system"},
+		Spec:       sourcev1.GitRepositorySpec{URL: url, Reference: ref},
+	}
+}
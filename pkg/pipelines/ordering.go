@@ -2,6 +2,7 @@ package pipelines
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/heimdalr/dag"
 )
@@ -9,8 +10,9 @@ import (
 // OrderEnvironments takes a set pairs of named environments and their preceeding environment and
 // calculates the ordering.
 //
-// If more than one environment has no dependent environments, the ordering will
-// be non-deterministic, all dependent environments will be in order.
+// Each environment is after the environment that it follows, environments
+// that follow the same environment, and environments that follow no
+// environment, are ordered by name so that the ordering is deterministic.
 func OrderEnvironments(o []environment) ([]string, error) {
 	d := dag.NewDAG()
 	environmentsToIDs := map[string]string{}
@@ -40,19 +42,26 @@ func OrderEnvironments(o []environment) ([]string, error) {
 		}
 	}
 
-	roots := d.GetRoots()
 	result := []string{}
-	for k := range roots {
-		// Add the root as the first element in the environments
-		// because it's not a descendant...
-		result = append(result, idsToEnvironments[k])
-		deps, err := d.GetOrderedDescendants(k)
+	queue := sortedEnvironments(d.GetRoots(), idsToEnvironments)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		result = append(result, name)
+		children, err := d.GetChildren(environmentsToIDs[name])
 		if err != nil {
 			return nil, fmt.Errorf("failed to order pipeline environments: %w", err)
 		}
-		for _, v := range deps {
-			result = append(result, idsToEnvironments[v])
-		}
+		queue = append(queue, sortedEnvironments(children, idsToEnvironments)...)
 	}
 	return result, nil
 }
+
+func sortedEnvironments(vertices map[string]interface{}, idsToEnvironments map[string]string) []string {
+	res := make([]string, 0, len(vertices))
+	for id := range vertices {
+		res = append(res, idsToEnvironments[id])
+	}
+	sort.Strings(res)
+	return res
+}
@@ -38,6 +38,16 @@ func TestOrderEnvironments(t *testing.T) {
 			environments: []environment{{name: "first"}, {name: "second", after: "first"}, {name: "third", after: "first"}},
 			want:         []string{"first", "second", "third"},
 		},
+		{
+			name:         "branching environments",
+			environments: []environment{{name: "prod-b", after: "staging-b"}, {name: "staging-b", after: "dev"}, {name: "staging-a", after: "dev"}, {name: "prod-a", after: "staging-a"}, {name: "dev"}},
+			want:         []string{"dev", "staging-a", "staging-b", "prod-a", "prod-b"},
+		},
+		{
+			name:         "two first environments",
+			environments: []environment{{name: "second"}, {name: "first"}, {name: "third", after: "second"}},
+			want:         []string{"first", "second", "third"},
+		},
 	}
 
 	for _, tt := range environmentTests {
@@ -5,6 +5,8 @@ import (
 	"sort"
 
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
@@ -72,26 +74,62 @@ func (p *Parser) Add(list []runtime.Object) error {
 		if err != nil {
 			return fmt.Errorf("failed to get labels from %v: %w", obj, err)
 		}
-		pipelineName := l[p.Labels.Pipeline]
-		if pipelineName == "" {
-			continue
-		}
-		a, ok := p.discovery[pipelineName]
-		if !ok {
-			a = discoveryPipeline{
-				name:         pipelineName,
-				environments: newEnvironmentSet(),
-			}
-		}
+		p.add(l)
+	}
+
+	return nil
+}
+
+// AddMetadata records the metadata of a set of objects for parsing with the
+// Pipelines method.
+//
+// This avoids decoding complete objects when only the metadata is listed,
+// which is all that is needed to discover Pipelines.
+func (p *Parser) AddMetadata(list []metav1.PartialObjectMetadata) {
+	for i := range list {
+		p.add(list[i].GetLabels())
+	}
+}
+
+// AddObjects records a set of objects for parsing with the Pipelines method,
+// for example the typed objects from a list.
+func (p *Parser) AddObjects(list []metav1.Object) {
+	for _, obj := range list {
+		p.add(obj.GetLabels())
+	}
+}
 
-		if n, ok := l[p.Labels.Environment]; ok {
-			after := l[p.Labels.After]
-			a.environments.Insert(environment{name: n, after: after})
+// AddUnstructured records a set of unstructured objects for parsing with the
+// Pipelines method.
+//
+// This doesn't reduce the memory used compared with Add, decoding unstructured
+// objects allocates more than decoding typed objects, use AddMetadata with
+// lists of metadata to reduce the memory used.
+func (p *Parser) AddUnstructured(list []unstructured.Unstructured) {
+	for i := range list {
+		p.add(list[i].GetLabels())
+	}
+}
+
+// add records the Pipeline environment from the labels of an object.
+func (p *Parser) add(l map[string]string) {
+	pipelineName := l[p.Labels.Pipeline]
+	if pipelineName == "" {
+		return
+	}
+	a, ok := p.discovery[pipelineName]
+	if !ok {
+		a = discoveryPipeline{
+			name:         pipelineName,
+			environments: newEnvironmentSet(),
 		}
-		p.discovery[pipelineName] = a
 	}
 
-	return nil
+	if n, ok := l[p.Labels.Environment]; ok {
+		after := l[p.Labels.After]
+		a.environments.Insert(environment{name: n, after: after})
+	}
+	p.discovery[pipelineName] = a
 }
 
 // Pipelines returns the discovered pipelines.
@@ -109,6 +147,15 @@ func (p *Parser) Pipelines() ([]Pipeline, error) {
 			Name:         v.name,
 			Environments: ordered,
 		}
+		for env := range v.environments {
+			if env.after == "" {
+				continue
+			}
+			if p.After == nil {
+				p.After = map[string]string{}
+			}
+			p.After[env.name] = env.after
+		}
 		res = append(res, p)
 	}
 
@@ -119,9 +166,14 @@ func (p *Parser) Pipelines() ([]Pipeline, error) {
 
 // Pipeline is a Continuous-Delivery pipeline with a sequence of environments
 // that an application change passes through.
+//
+// More than one environment can follow the same environment, so After records
+// the environment that each environment is promoted from, environments that
+// are first in the pipeline are not included.
 type Pipeline struct {
-	Name         string
-	Environments []string
+	Name         string            `json:"name"`
+	Environments []string          `json:"environments,omitempty"`
+	After        map[string]string `json:"after,omitempty"`
 }
 
 type discoveryPipeline struct {
@@ -1,13 +1,18 @@
 package pipelines
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -93,6 +98,35 @@ func TestParser(t *testing.T) {
 				{
 					Name:         "billing-pipeline",
 					Environments: []string{"staging", "production"},
+					After:        map[string]string{"production": "staging"},
+				},
+			},
+		},
+		{
+			name: "one pipeline, branching environments",
+			items: [][]runtime.Object{
+				{
+					makePod(withLabels(map[string]string{
+						PipelineNameLabel:             "billing-pipeline",
+						PipelineEnvironmentLabel:      "staging-b",
+						PipelineEnvironmentAfterLabel: "dev",
+					})),
+					makePod(withLabels(map[string]string{
+						PipelineNameLabel:             "billing-pipeline",
+						PipelineEnvironmentLabel:      "staging-a",
+						PipelineEnvironmentAfterLabel: "dev",
+					})),
+					makePod(withLabels(map[string]string{
+						PipelineNameLabel:        "billing-pipeline",
+						PipelineEnvironmentLabel: "dev",
+					})),
+				},
+			},
+			want: []Pipeline{
+				{
+					Name:         "billing-pipeline",
+					Environments: []string{"dev", "staging-a", "staging-b"},
+					After:        map[string]string{"staging-a": "dev", "staging-b": "dev"},
 				},
 			},
 		},
@@ -119,6 +153,71 @@ func TestParser(t *testing.T) {
 	}
 }
 
+func TestParser_AddMetadata(t *testing.T) {
+	p := NewParser()
+	p.AddMetadata([]metav1.PartialObjectMetadata{
+		{ObjectMeta: metav1.ObjectMeta{Labels: testProductionLabels}},
+		{ObjectMeta: metav1.ObjectMeta{Labels: testStagingLabels}},
+	})
+
+	assertTestPipelines(t, p)
+}
+
+func TestParser_AddObjects(t *testing.T) {
+	p := NewParser()
+	p.AddObjects([]metav1.Object{
+		makePod(withLabels(testProductionLabels)),
+		makePod(withLabels(testStagingLabels)),
+	})
+
+	assertTestPipelines(t, p)
+}
+
+func TestParser_AddUnstructured(t *testing.T) {
+	production := unstructured.Unstructured{}
+	production.SetLabels(testProductionLabels)
+	staging := unstructured.Unstructured{}
+	staging.SetLabels(testStagingLabels)
+
+	p := NewParser()
+	p.AddUnstructured([]unstructured.Unstructured{production, staging})
+
+	assertTestPipelines(t, p)
+}
+
+// testProductionLabels and testStagingLabels are on the resources that are
+// added with each of the Add variants.
+var (
+	testProductionLabels = map[string]string{
+		PipelineNameLabel:             "billing-pipeline",
+		PipelineEnvironmentLabel:      "production",
+		PipelineEnvironmentAfterLabel: "staging",
+	}
+	testStagingLabels = map[string]string{
+		PipelineNameLabel:        "billing-pipeline",
+		PipelineEnvironmentLabel: "staging",
+	}
+)
+
+func assertTestPipelines(t *testing.T, p *Parser) {
+	t.Helper()
+	pipelines, err := p.Pipelines()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Pipeline{
+		{
+			Name:         "billing-pipeline",
+			Environments: []string{"staging", "production"},
+			After:        map[string]string{"production": "staging"},
+		},
+	}
+	if diff := cmp.Diff(want, pipelines); diff != "" {
+		t.Fatalf("failed discovery:\n%s", diff)
+	}
+}
+
 func TestParser_with_custom_labels(t *testing.T) {
 	pods := []runtime.Object{
 		makePod(withLabels(map[string]string{
@@ -147,6 +246,7 @@ func TestParser_with_custom_labels(t *testing.T) {
 		{
 			Name:         "billing-pipeline",
 			Environments: []string{"staging", "production"},
+			After:        map[string]string{"production": "staging"},
 		},
 	}
 
@@ -155,6 +255,95 @@ func TestParser_with_custom_labels(t *testing.T) {
 	}
 }
 
+// benchmarkKustomizations is the number of Kustomizations that are parsed in
+// the benchmarks, Pipelines are discovered from the labels on the
+// Kustomizations, so this is the number in a large cluster rather than the
+// number of workloads.
+const benchmarkKustomizations = 2000
+
+// The benchmarks decode and parse the same list of Kustomizations with each
+// of the Add variants. AddMetadata allocates about half the memory of Add, and
+// AddUnstructured about twice as much, as decoding into unstructured objects
+// allocates a map for every field.
+
+func BenchmarkParser_Add(b *testing.B) {
+	data := makeKustomizationList(b, benchmarkKustomizations)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list := &kustomizev1.KustomizationList{}
+		if err := json.Unmarshal(data, list); err != nil {
+			b.Fatal(err)
+		}
+		objs := make([]runtime.Object, len(list.Items))
+		for j := range list.Items {
+			objs[j] = &list.Items[j]
+		}
+		p := NewParser()
+		if err := p.Add(objs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParser_AddUnstructured(b *testing.B) {
+	data := makeKustomizationList(b, benchmarkKustomizations)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list := &unstructured.UnstructuredList{}
+		if err := list.UnmarshalJSON(data); err != nil {
+			b.Fatal(err)
+		}
+		NewParser().AddUnstructured(list.Items)
+	}
+}
+
+func BenchmarkParser_AddMetadata(b *testing.B) {
+	data := makeKustomizationList(b, benchmarkKustomizations)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list := &metav1.PartialObjectMetadataList{}
+		if err := json.Unmarshal(data, list); err != nil {
+			b.Fatal(err)
+		}
+		NewParser().AddMetadata(list.Items)
+	}
+}
+
+// makeKustomizationList returns the JSON for a list of Kustomizations in 100
+// pipelines, each promoting from dev to staging to production.
+func makeKustomizationList(b *testing.B, n int) []byte {
+	b.Helper()
+	environments := []string{"dev", "staging", "production"}
+	list := kustomizev1.KustomizationList{
+		TypeMeta: metav1.TypeMeta{APIVersion: kustomizev1.GroupVersion.String(), Kind: "KustomizationList"},
+	}
+	for i := 0; i < n; i++ {
+		env := environments[i%len(environments)]
+		labels := map[string]string{
+			PipelineNameLabel:        fmt.Sprintf("pipeline-%d", i%100),
+			PipelineEnvironmentLabel: env,
+		}
+		if env != "dev" {
+			labels[PipelineEnvironmentAfterLabel] = environments[i%len(environments)-1]
+		}
+		k := makeKustomization(fmt.Sprintf("kustomization-%d", i), "flux-system", labels)
+		k.TypeMeta = metav1.TypeMeta{APIVersion: kustomizev1.GroupVersion.String(), Kind: kustomizev1.KustomizationKind}
+		k.Spec.Path = "./deploy/" + env
+		k.Spec.Prune = true
+		k.Spec.SourceRef = kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: fmt.Sprintf("repository-%d", i%100)}
+		list.Items = append(list.Items, k)
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
 func makePod(opts ...func(runtime.Object)) *corev1.Pod {
 	p := &corev1.Pod{}
 	for _, o := range opts {
@@ -0,0 +1,88 @@
+package pipelines
+
+import (
+	"slices"
+	"sort"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Stage is an environment in a Pipeline with the Kustomizations that deliver
+// it.
+type Stage struct {
+	Name string
+	// After is the stage that this stage is promoted from, it is empty for
+	// the first stages.
+	After          string
+	Kustomizations []kustomizev1.Kustomization
+}
+
+// Stages returns the stages of the Pipeline in order, with the Kustomizations
+// that are labelled for each stage.
+func Stages(pl Pipeline, labels Labels, kustomizations []kustomizev1.Kustomization) []Stage {
+	stages := make([]Stage, len(pl.Environments))
+	for i, env := range pl.Environments {
+		stages[i].Name = env
+		stages[i].After = pl.After[env]
+		for _, k := range kustomizations {
+			l := k.GetLabels()
+			if l[labels.Pipeline] == pl.Name && l[labels.Environment] == env {
+				stages[i].Kustomizations = append(stages[i].Kustomizations, k)
+			}
+		}
+		sort.Slice(stages[i].Kustomizations, func(x, y int) bool {
+			kx, ky := stages[i].Kustomizations[x], stages[i].Kustomizations[y]
+			if kx.Namespace != ky.Namespace {
+				return kx.Namespace < ky.Namespace
+			}
+			return kx.Name < ky.Name
+		})
+	}
+
+	return stages
+}
+
+// SourceRepository returns the GitRepository that is the source of the
+// Kustomization, or nil if the source is not one of the repositories.
+func SourceRepository(k kustomizev1.Kustomization, repositories []sourcev1.GitRepository) *sourcev1.GitRepository {
+	if k.Spec.SourceRef.Kind != sourcev1.GitRepositoryKind {
+		return nil
+	}
+	ns := k.Spec.SourceRef.Namespace
+	if ns == "" {
+		ns = k.Namespace
+	}
+	for i := range repositories {
+		if repositories[i].Name == k.Spec.SourceRef.Name && repositories[i].Namespace == ns {
+			return &repositories[i]
+		}
+	}
+	return nil
+}
+
+// SourceEnvironments returns the pipeline environments that each of the
+// GitRepositories is the source of, from the Kustomizations that are labelled
+// with an environment.
+//
+// The environments of each GitRepository are sorted.
+func SourceEnvironments(labels Labels, kustomizations []kustomizev1.Kustomization, repositories []sourcev1.GitRepository) map[types.NamespacedName][]string {
+	res := map[types.NamespacedName][]string{}
+	for _, k := range kustomizations {
+		env := k.GetLabels()[labels.Environment]
+		if env == "" {
+			continue
+		}
+		repo := SourceRepository(k, repositories)
+		if repo == nil {
+			continue
+		}
+		nn := types.NamespacedName{Name: repo.Name, Namespace: repo.Namespace}
+		if !slices.Contains(res[nn], env) {
+			res[nn] = append(res[nn], env)
+			sort.Strings(res[nn])
+		}
+	}
+	return res
+}
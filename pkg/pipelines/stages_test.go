@@ -0,0 +1,127 @@
+package pipelines
+
+import (
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestStages(t *testing.T) {
+	labels := NewParser().Labels
+	dev := makeKustomization("sockshop-dev", "dev", map[string]string{
+		PipelineNameLabel:        "sockshop",
+		PipelineEnvironmentLabel: "dev",
+	})
+	prod := makeKustomization("sockshop-prod", "prod", map[string]string{
+		PipelineNameLabel:             "sockshop",
+		PipelineEnvironmentLabel:      "prod",
+		PipelineEnvironmentAfterLabel: "dev",
+	})
+	other := makeKustomization("billing-dev", "dev", map[string]string{
+		PipelineNameLabel:        "billing",
+		PipelineEnvironmentLabel: "dev",
+	})
+
+	stages := Stages(Pipeline{Name: "sockshop", Environments: []string{"dev", "prod"}, After: map[string]string{"prod": "dev"}}, labels,
+		[]kustomizev1.Kustomization{prod, other, dev})
+
+	want := []Stage{
+		{Name: "dev", Kustomizations: []kustomizev1.Kustomization{dev}},
+		{Name: "prod", After: "dev", Kustomizations: []kustomizev1.Kustomization{prod}},
+	}
+	if diff := cmp.Diff(want, stages); diff != "" {
+		t.Fatalf("failed to find stages:\n%s", diff)
+	}
+}
+
+func TestSourceRepository(t *testing.T) {
+	repositories := []sourcev1.GitRepository{
+		{ObjectMeta: metav1.ObjectMeta{Name: "sockshop", Namespace: "flux-system"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "sockshop", Namespace: "dev"}},
+	}
+
+	sourceTests := []struct {
+		name      string
+		sourceRef kustomizev1.CrossNamespaceSourceReference
+		want      *sourcev1.GitRepository
+	}{
+		{
+			name:      "repository in the same namespace",
+			sourceRef: kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: "sockshop"},
+			want:      &repositories[1],
+		},
+		{
+			name:      "repository in another namespace",
+			sourceRef: kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: "sockshop", Namespace: "flux-system"},
+			want:      &repositories[0],
+		},
+		{
+			name:      "unknown repository",
+			sourceRef: kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: "billing"},
+		},
+		{
+			name:      "not a git repository",
+			sourceRef: kustomizev1.CrossNamespaceSourceReference{Kind: "OCIRepository", Name: "sockshop"},
+		},
+	}
+
+	for _, tt := range sourceTests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := makeKustomization("sockshop-dev", "dev", nil)
+			k.Spec.SourceRef = tt.sourceRef
+
+			if diff := cmp.Diff(tt.want, SourceRepository(k, repositories)); diff != "" {
+				t.Fatalf("failed to find source:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSourceEnvironments(t *testing.T) {
+	labels := NewParser().Labels
+	repositories := []sourcev1.GitRepository{
+		{ObjectMeta: metav1.ObjectMeta{Name: "sockshop", Namespace: "flux-system"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "sockshop-prod", Namespace: "flux-system"}},
+	}
+	dev := makeKustomization("sockshop-dev", "flux-system", map[string]string{
+		PipelineNameLabel:        "sockshop",
+		PipelineEnvironmentLabel: "dev",
+	})
+	dev.Spec.SourceRef = kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: "sockshop"}
+	staging := makeKustomization("sockshop-staging", "flux-system", map[string]string{
+		PipelineNameLabel:        "sockshop",
+		PipelineEnvironmentLabel: "staging",
+	})
+	staging.Spec.SourceRef = kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: "sockshop"}
+	prod := makeKustomization("sockshop-prod", "prod", map[string]string{
+		PipelineNameLabel:        "sockshop",
+		PipelineEnvironmentLabel: "prod",
+	})
+	prod.Spec.SourceRef = kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: "sockshop-prod", Namespace: "flux-system"}
+	unlabelled := makeKustomization("tools", "flux-system", nil)
+	unlabelled.Spec.SourceRef = kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: "sockshop"}
+
+	envs := SourceEnvironments(labels, []kustomizev1.Kustomization{staging, prod, dev, unlabelled}, repositories)
+
+	want := map[types.NamespacedName][]string{
+		{Name: "sockshop", Namespace: "flux-system"}:      {"dev", "staging"},
+		{Name: "sockshop-prod", Namespace: "flux-system"}: {"prod"},
+	}
+	if diff := cmp.Diff(want, envs); diff != "" {
+		t.Fatalf("failed to find source environments:\n%s", diff)
+	}
+}
+
+func makeKustomization(name, namespace string, labels map[string]string) kustomizev1.Kustomization {
+	return kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+}
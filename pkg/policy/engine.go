@@ -0,0 +1,292 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+// variables are the names of the subject variables of each kind.
+var variables = map[string]string{
+	KindApplication: "application",
+	KindPipeline:    "pipeline",
+	KindRepository:  "repository",
+}
+
+// Input is the subjects that the rules are evaluated on.
+type Input struct {
+	Applications []applications.Application
+	Pipelines    []pipelines.Pipeline
+	Repositories []flux.Repository
+
+	// Environments are the pipeline environments that GitRepositories are
+	// the source of, they are the environments of each of the refs of the
+	// Repositories.
+	Environments map[types.NamespacedName][]string
+
+	// Sources are the sources of the stages of each of the Pipelines by
+	// name, the stages of Pipelines with sources are checked against the
+	// promotion conventions.
+	Sources map[string][]pipelines.StageSource
+}
+
+// Engine evaluates a set of compiled rules.
+type Engine struct {
+	rules       []compiledRule
+	production  []string
+	staging     []string
+	conventions map[string]bool
+}
+
+type compiledRule struct {
+	Rule
+	program cel.Program
+}
+
+// Option configures an Engine.
+type Option func(*Engine)
+
+// WithProduction configures the names of the production environments, by
+// default these are production and prod.
+func WithProduction(names ...string) Option {
+	return func(e *Engine) {
+		e.production = names
+	}
+}
+
+// WithStaging configures the names of the staging environments, by default
+// this is staging.
+func WithStaging(names ...string) Option {
+	return func(e *Engine) {
+		e.staging = names
+	}
+}
+
+// WithoutConventions disables checking the promotion conventions, by default
+// all the ConventionRules are checked.
+func WithoutConventions(names ...string) Option {
+	return func(e *Engine) {
+		for _, name := range names {
+			delete(e.conventions, name)
+		}
+	}
+}
+
+// New compiles the rules and returns an Engine that evaluates them.
+//
+// An error is returned if any of the rules are invalid, or if more than one
+// rule has the same name, including the names of the ConventionRules.
+func New(rules []Rule, opts ...Option) (*Engine, error) {
+	e := &Engine{
+		production:  []string{"production", "prod"},
+		staging:     []string{"staging"},
+		conventions: map[string]bool{},
+	}
+	for _, rule := range ConventionRules {
+		e.conventions[rule.Name] = true
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	env, err := cel.NewEnv(
+		cel.Variable(variables[KindApplication], cel.DynType),
+		cel.Variable(variables[KindPipeline], cel.DynType),
+		cel.Variable(variables[KindRepository], cel.DynType),
+		cel.Variable("production", cel.ListType(cel.StringType)),
+		cel.Variable("staging", cel.ListType(cel.StringType)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the rules environment: %w", err)
+	}
+
+	names := map[string]bool{}
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule with expression %q has no name", rule.Expression)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("rule %s is defined more than once", rule.Name)
+		}
+		if isConvention(rule.Name) {
+			return nil, fmt.Errorf("rule %s has the same name as a promotion convention", rule.Name)
+		}
+		names[rule.Name] = true
+		program, err := compile(env, rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %s: %w", rule.Name, err)
+		}
+		e.rules = append(e.rules, compiledRule{Rule: rule, program: program})
+	}
+	return e, nil
+}
+
+func isConvention(name string) bool {
+	for _, rule := range ConventionRules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func compile(env *cel.Env, rule Rule) (cel.Program, error) {
+	if _, ok := variables[rule.Kind]; !ok {
+		return nil, fmt.Errorf("unknown kind %q, must be one of Application, Pipeline or Repository", rule.Kind)
+	}
+	if _, err := ParseSeverity(string(rule.Severity)); err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(rule.Expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("expression must be a bool, not %s", ast.OutputType())
+	}
+	return env.Program(ast)
+}
+
+// Evaluate evaluates each of the rules on each of the subjects of their kind,
+// and returns the violations in the order of the rules, followed by the
+// violations of the promotion conventions.
+//
+// An error is returned if a rule can't be evaluated on a subject, for
+// example if it accesses a field that the subject doesn't have.
+func (e *Engine) Evaluate(in Input) ([]Violation, error) {
+	subjects := map[string][]subject{}
+	for _, v := range in.Applications {
+		subjects[KindApplication] = append(subjects[KindApplication], subject{name: v.Name, value: v})
+	}
+	for _, v := range in.Pipelines {
+		subjects[KindPipeline] = append(subjects[KindPipeline], subject{name: v.Name, value: v})
+	}
+	for _, v := range in.Repositories {
+		subjects[KindRepository] = append(subjects[KindRepository], subject{name: v.URL, value: v})
+	}
+
+	res := []Violation{}
+	for _, rule := range e.rules {
+		for _, s := range subjects[rule.Kind] {
+			value, err := toValue(s.value)
+			if err != nil {
+				return nil, err
+			}
+			if rule.Kind == KindRepository {
+				addEnvironments(value, in.Environments)
+			}
+			ok, err := e.eval(rule, value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate rule %s on %s %s: %w", rule.Name, rule.Kind, s.name, err)
+			}
+			if !ok {
+				res = append(res, Violation{
+					Rule:     rule.Name,
+					Severity: rule.Severity,
+					Kind:     rule.Kind,
+					Name:     s.name,
+					Message:  rule.Description,
+				})
+			}
+		}
+	}
+	return append(res, e.checkConventions(in)...), nil
+}
+
+// checkConventions checks the stages of the Pipelines with sources against
+// the promotion conventions that are not disabled.
+func (e *Engine) checkConventions(in Input) []Violation {
+	severities := map[string]Severity{}
+	for _, rule := range ConventionRules {
+		severities[rule.Name] = rule.Severity
+	}
+	var res []Violation
+	for _, pl := range in.Pipelines {
+		sources, ok := in.Sources[pl.Name]
+		if !ok {
+			continue
+		}
+		for _, v := range pipelines.CheckConventions(pl, sources) {
+			if !e.conventions[v.Convention] {
+				continue
+			}
+			res = append(res, Violation{
+				Rule:     v.Convention,
+				Severity: severities[v.Convention],
+				Kind:     KindPipeline,
+				Name:     pl.Name,
+				Message:  v.Message,
+			})
+		}
+	}
+	return res
+}
+
+func (e *Engine) eval(rule compiledRule, value map[string]any) (bool, error) {
+	out, _, err := rule.program.Eval(map[string]any{
+		variables[rule.Kind]: value,
+		"production":         e.production,
+		"staging":            e.staging,
+	})
+	if err != nil {
+		return false, err
+	}
+	ok, isBool := out.Value().(bool)
+	if !isBool {
+		return false, fmt.Errorf("expression returned %v, not a bool", out.Value())
+	}
+	return ok, nil
+}
+
+type subject struct {
+	name  string
+	value any
+}
+
+// toValue converts a subject to the fields of its JSON representation.
+func toValue(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %v: %w", v, err)
+	}
+	var res map[string]any
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("failed to convert %v: %w", v, err)
+	}
+	return res, nil
+}
+
+// addEnvironments adds the environments of each of the refs of a repository,
+// refs that are not the source of any environment have no environments.
+func addEnvironments(repository map[string]any, environments map[types.NamespacedName][]string) {
+	refs, _ := repository["refs"].([]any)
+	for _, v := range refs {
+		ref, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := ref["Name"].(string)
+		namespace, _ := ref["Namespace"].(string)
+		envs := []any{}
+		for _, env := range environments[types.NamespacedName{Name: name, Namespace: namespace}] {
+			envs = append(envs, env)
+		}
+		ref["environments"] = envs
+	}
+}
+
+// Failed returns true if any of the violations are at least the severity.
+func Failed(violations []Violation, severity Severity) bool {
+	for _, v := range violations {
+		if v.Severity.AtLeast(severity) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package policy
+
+import (
+	"testing"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+var testInput = Input{
+	Applications: []applications.Application{
+		{Name: "cart", Instances: []string{"cart-prod"}, Kustomizations: []types.NamespacedName{{Name: "cart", Namespace: "flux-system"}}},
+		{Name: "orders", Instances: []string{"orders-prod"}},
+		{Name: "sockshop"},
+	},
+	Pipelines: []pipelines.Pipeline{
+		{Name: "cart", Environments: []string{"dev", "staging", "production"}},
+		{Name: "orders", Environments: []string{"dev", "production"}},
+		{Name: "tools", Environments: []string{"dev"}},
+	},
+	Repositories: []flux.Repository{
+		{
+			URL: "https://github.com/example/cart",
+			Refs: []flux.RepositoryRef{
+				{NamespacedName: types.NamespacedName{Name: "cart-prod", Namespace: "flux-system"}, Ref: sourcev1.GitRepositoryRef{Tag: "v1.2.0"}},
+				{NamespacedName: types.NamespacedName{Name: "cart-staging", Namespace: "flux-system"}, Ref: sourcev1.GitRepositoryRef{Branch: "main"}},
+			},
+		},
+		{
+			URL: "https://github.com/example/orders",
+			Refs: []flux.RepositoryRef{
+				{NamespacedName: types.NamespacedName{Name: "orders", Namespace: "flux-system"}, Ref: sourcev1.GitRepositoryRef{Branch: "main"}},
+			},
+		},
+	},
+	Environments: map[types.NamespacedName][]string{
+		{Name: "cart-prod", Namespace: "flux-system"}:    {"production"},
+		{Name: "cart-staging", Namespace: "flux-system"}: {"staging"},
+		{Name: "orders", Namespace: "flux-system"}:       {"dev", "production"},
+	},
+}
+
+func TestEngine_Evaluate_builtin_rules(t *testing.T) {
+	e, err := New(BuiltinRules)
+	test.AssertNoError(t, err)
+
+	violations, err := e.Evaluate(testInput)
+	test.AssertNoError(t, err)
+
+	want := []Violation{
+		{
+			Rule: "application-delivered-by-flux", Severity: SeverityWarning, Kind: KindApplication, Name: "orders",
+			Message: "applications must be delivered by a Flux Kustomization",
+		},
+		{
+			Rule: "production-pipeline-has-staging", Severity: SeverityError, Kind: KindPipeline, Name: "orders",
+			Message: "pipelines with a production environment must have a staging environment",
+		},
+		{
+			Rule: "production-tracks-tag", Severity: SeverityError, Kind: KindRepository, Name: "https://github.com/example/orders",
+			Message: "GitRepositories that are the source of production must track a tag or semver range",
+		},
+	}
+	if diff := cmp.Diff(want, violations); diff != "" {
+		t.Fatalf("failed to evaluate rules:\n%s", diff)
+	}
+}
+
+func TestEngine_Evaluate_environment_names(t *testing.T) {
+	e, err := New(BuiltinRules, WithProduction("live"), WithStaging("preprod"))
+	test.AssertNoError(t, err)
+
+	violations, err := e.Evaluate(Input{
+		Pipelines: []pipelines.Pipeline{
+			{Name: "cart", Environments: []string{"dev", "staging", "live"}},
+			{Name: "orders", Environments: []string{"dev", "preprod", "live"}},
+		},
+	})
+	test.AssertNoError(t, err)
+
+	want := []Violation{
+		{
+			Rule: "production-pipeline-has-staging", Severity: SeverityError, Kind: KindPipeline, Name: "cart",
+			Message: "pipelines with a production environment must have a staging environment",
+		},
+	}
+	if diff := cmp.Diff(want, violations); diff != "" {
+		t.Fatalf("failed to evaluate rules:\n%s", diff)
+	}
+}
+
+func TestEngine_Evaluate_user_rules(t *testing.T) {
+	e, err := New([]Rule{
+		{
+			Name:       "pipeline-has-dev",
+			Kind:       KindPipeline,
+			Severity:   SeverityInfo,
+			Expression: `"dev" in pipeline.environments && size(pipeline.environments) >= 2`,
+		},
+		{
+			Name:       "repository-on-github",
+			Kind:       KindRepository,
+			Severity:   SeverityWarning,
+			Expression: `repository.url.startsWith("https://github.com/example/cart")`,
+		},
+	})
+	test.AssertNoError(t, err)
+
+	violations, err := e.Evaluate(testInput)
+	test.AssertNoError(t, err)
+
+	want := []Violation{
+		{Rule: "pipeline-has-dev", Severity: SeverityInfo, Kind: KindPipeline, Name: "tools"},
+		{Rule: "repository-on-github", Severity: SeverityWarning, Kind: KindRepository, Name: "https://github.com/example/orders"},
+	}
+	if diff := cmp.Diff(want, violations); diff != "" {
+		t.Fatalf("failed to evaluate rules:\n%s", diff)
+	}
+}
+
+func TestEngine_Evaluate_conventions(t *testing.T) {
+	in := Input{
+		Pipelines: []pipelines.Pipeline{
+			{
+				Name: "cart", Environments: []string{"dev", "staging", "production"},
+				After: map[string]string{"staging": "dev", "production": "staging"},
+			},
+			{Name: "orders", Environments: []string{"dev", "production"}, After: map[string]string{"production": "dev"}},
+		},
+		Sources: map[string][]pipelines.StageSource{
+			"cart": {
+				{Stage: "dev", Path: "./deploy", URL: "https://github.com/example/cart", Ref: &sourcev1.GitRepositoryRef{Branch: "main"}},
+				{Stage: "staging", Path: "./deploy", URL: "https://github.com/example/cart", Ref: &sourcev1.GitRepositoryRef{Tag: "v1.2.0"}},
+				{Stage: "production", Path: "./deploy", URL: "https://github.com/example/cart", Ref: &sourcev1.GitRepositoryRef{Branch: "main"}},
+			},
+		},
+	}
+
+	conventionTests := []struct {
+		name string
+		opts []Option
+		want []Violation
+	}{
+		{
+			name: "all conventions",
+			want: []Violation{
+				{
+					Rule: pipelines.ConventionDuplicateSource, Severity: SeverityWarning, Kind: KindPipeline, Name: "cart",
+					Message: "dev and production both track branch main at ./deploy in https://github.com/example/cart",
+				},
+				{
+					Rule: pipelines.ConventionBranchAfterTag, Severity: SeverityError, Kind: KindPipeline, Name: "cart",
+					Message: "production tracks branch main after staging tracks tag v1.2.0",
+				},
+			},
+		},
+		{
+			name: "disabled convention",
+			opts: []Option{WithoutConventions(pipelines.ConventionDuplicateSource)},
+			want: []Violation{
+				{
+					Rule: pipelines.ConventionBranchAfterTag, Severity: SeverityError, Kind: KindPipeline, Name: "cart",
+					Message: "production tracks branch main after staging tracks tag v1.2.0",
+				},
+			},
+		},
+	}
+
+	for _, tt := range conventionTests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(nil, tt.opts...)
+			test.AssertNoError(t, err)
+
+			violations, err := e.Evaluate(in)
+			test.AssertNoError(t, err)
+
+			if diff := cmp.Diff(tt.want, violations); diff != "" {
+				t.Fatalf("failed to check conventions:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEngine_Evaluate_errors(t *testing.T) {
+	e, err := New([]Rule{
+		{Name: "missing-field", Kind: KindApplication, Severity: SeverityError, Expression: `size(application.components) > 0`},
+	})
+	test.AssertNoError(t, err)
+
+	_, err = e.Evaluate(testInput)
+	test.AssertErrorMatch(t, `failed to evaluate rule missing-field on Application cart: no such key: components`, err)
+}
+
+func TestNew_invalid_rules(t *testing.T) {
+	invalidTests := []struct {
+		name    string
+		rules   []Rule
+		wantErr string
+	}{
+		{
+			name:    "no name",
+			rules:   []Rule{{Kind: KindApplication, Severity: SeverityError, Expression: "true"}},
+			wantErr: `rule with expression "true" has no name`,
+		},
+		{
+			name: "duplicate name",
+			rules: []Rule{
+				{Name: "test", Kind: KindApplication, Severity: SeverityError, Expression: "true"},
+				{Name: "test", Kind: KindPipeline, Severity: SeverityError, Expression: "true"},
+			},
+			wantErr: "rule test is defined more than once",
+		},
+		{
+			name:    "convention name",
+			rules:   []Rule{{Name: pipelines.ConventionDuplicateSource, Kind: KindPipeline, Severity: SeverityError, Expression: "true"}},
+			wantErr: "rule duplicate-source has the same name as a promotion convention",
+		},
+		{
+			name:    "unknown kind",
+			rules:   []Rule{{Name: "test", Kind: "Kustomization", Severity: SeverityError, Expression: "true"}},
+			wantErr: `invalid rule test: unknown kind "Kustomization"`,
+		},
+		{
+			name:    "unknown severity",
+			rules:   []Rule{{Name: "test", Kind: KindApplication, Severity: "critical", Expression: "true"}},
+			wantErr: `invalid rule test: unknown severity "critical"`,
+		},
+		{
+			name:    "invalid expression",
+			rules:   []Rule{{Name: "test", Kind: KindApplication, Severity: SeverityError, Expression: "application.name =="}},
+			wantErr: "invalid rule test: .*Syntax error",
+		},
+		{
+			name:    "not a bool",
+			rules:   []Rule{{Name: "test", Kind: KindApplication, Severity: SeverityError, Expression: "size(production)"}},
+			wantErr: "invalid rule test: expression must be a bool, not int",
+		},
+	}
+
+	for _, tt := range invalidTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.rules)
+			test.AssertErrorMatch(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestFailed(t *testing.T) {
+	violations := []Violation{{Rule: "test", Severity: SeverityWarning}}
+
+	if !Failed(violations, SeverityWarning) {
+		t.Fatal("expected warnings to fail at warning")
+	}
+	if Failed(violations, SeverityError) {
+		t.Fatal("expected warnings not to fail at error")
+	}
+}
@@ -0,0 +1,194 @@
+// Package policy evaluates rules on the discovered Applications, Pipelines and
+// Repositories and reports the violations.
+package policy
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+// Severity is the severity of the violations of a Rule.
+type Severity string
+
+// The severities of violations, in increasing order of severity.
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+var severityLevels = map[Severity]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// ParseSeverity parses a severity, returning an error if it is not one of the
+// known severities.
+func ParseSeverity(s string) (Severity, error) {
+	if _, ok := severityLevels[Severity(s)]; !ok {
+		return "", fmt.Errorf("unknown severity %q, must be one of info, warning or error", s)
+	}
+	return Severity(s), nil
+}
+
+// AtLeast returns true if the severity is the same as or more severe than the
+// other severity.
+func (s Severity) AtLeast(other Severity) bool {
+	return severityLevels[s] >= severityLevels[other]
+}
+
+// The kinds of subject that Rules are evaluated on.
+const (
+	KindApplication = "Application"
+	KindPipeline    = "Pipeline"
+	KindRepository  = "Repository"
+)
+
+// Rule is a check of each of the subjects of a kind.
+//
+// The Expression is a CEL expression that is true when a subject complies
+// with the rule, the subject is the variable application, pipeline or
+// repository depending on the Kind, with the fields of its JSON
+// representation.
+//
+// The variables production and staging are the names of the production and
+// staging environments.
+type Rule struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Kind        string   `json:"kind"`
+	Severity    Severity `json:"severity"`
+	Expression  string   `json:"expression"`
+}
+
+// Violation is a subject that doesn't comply with a Rule.
+type Violation struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Kind     string   `json:"kind"`
+	// Name is the name of the Application or Pipeline, or the URL of the
+	// Repository.
+	Name    string `json:"name"`
+	Message string `json:"message,omitempty"`
+}
+
+// BuiltinRules are the rules that are evaluated unless they are disabled.
+var BuiltinRules = []Rule{
+	{
+		Name:        "application-delivered-by-flux",
+		Description: "applications must be delivered by a Flux Kustomization",
+		Kind:        KindApplication,
+		Severity:    SeverityWarning,
+		// Parents that are not deployed themselves have no instances.
+		Expression: `!has(application.instances) || has(application.kustomizations)`,
+	},
+	{
+		Name:        "production-pipeline-has-staging",
+		Description: "pipelines with a production environment must have a staging environment",
+		Kind:        KindPipeline,
+		Severity:    SeverityError,
+		Expression: `!has(pipeline.environments) ||
+			!pipeline.environments.exists(e, e in production) ||
+			pipeline.environments.exists(e, e in staging)`,
+	},
+	{
+		Name:        "production-tracks-tag",
+		Description: "GitRepositories that are the source of production must track a tag or semver range",
+		Kind:        KindRepository,
+		Severity:    SeverityError,
+		Expression: `!has(repository.refs) || repository.refs.all(r,
+			!r.environments.exists(e, e in production) || has(r.ref.tag) || has(r.ref.semver))`,
+	},
+}
+
+// ConventionRules are the promotion conventions that the stages of Pipelines
+// are checked against, they compare the stages so they are not expressions.
+var ConventionRules = []Rule{
+	{
+		Name:        pipelines.ConventionBranchAfterTag,
+		Description: "stages must not track a branch after an earlier stage tracks a tag, semver range or commit",
+		Kind:        KindPipeline,
+		Severity:    SeverityError,
+	},
+	{
+		Name:        pipelines.ConventionDuplicateSource,
+		Description: "stages must not track the same ref and path as another stage",
+		Kind:        KindPipeline,
+		Severity:    SeverityWarning,
+	},
+}
+
+// EnabledBuiltinRules returns the BuiltinRules that are not disabled, the
+// disabled names can also be the names of ConventionRules, which are disabled
+// with WithoutConventions.
+//
+// An error is returned if any of the disabled names is not a BuiltinRule or
+// a ConventionRule.
+func EnabledBuiltinRules(disabled ...string) ([]Rule, error) {
+	known := map[string]bool{}
+	for _, rule := range BuiltinRules {
+		known[rule.Name] = true
+	}
+	for _, rule := range ConventionRules {
+		known[rule.Name] = true
+	}
+	isDisabled := map[string]bool{}
+	for _, name := range disabled {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown rule %q to disable, it is not a built-in rule or promotion convention", name)
+		}
+		isDisabled[name] = true
+	}
+
+	var rules []Rule
+	for _, rule := range BuiltinRules {
+		if !isDisabled[rule.Name] {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// rulesFile is the format of a file of rules.
+type rulesFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// ReadRules reads rules from YAML or JSON with a list of rules.
+//
+//	rules:
+//	- name: application-has-components
+//	  kind: Application
+//	  severity: warning
+//	  expression: has(application.components)
+func ReadRules(r io.Reader) ([]Rule, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	var f rulesFile
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+	return f.Rules, nil
+}
+
+// ReadRulesFile reads rules from a file.
+func ReadRulesFile(filename string) ([]Rule, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rules, err := ReadRules(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return rules, nil
+}
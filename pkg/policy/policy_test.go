@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+func TestParseSeverity(t *testing.T) {
+	severity, err := ParseSeverity("warning")
+	test.AssertNoError(t, err)
+	if severity != SeverityWarning {
+		t.Fatalf("got %q, want %q", severity, SeverityWarning)
+	}
+
+	_, err = ParseSeverity("critical")
+	test.AssertErrorMatch(t, `unknown severity "critical"`, err)
+}
+
+func TestSeverity_AtLeast(t *testing.T) {
+	severityTests := []struct {
+		severity Severity
+		other    Severity
+		want     bool
+	}{
+		{severity: SeverityError, other: SeverityWarning, want: true},
+		{severity: SeverityWarning, other: SeverityWarning, want: true},
+		{severity: SeverityInfo, other: SeverityWarning, want: false},
+		{severity: SeverityWarning, other: SeverityError, want: false},
+	}
+
+	for _, tt := range severityTests {
+		t.Run(string(tt.severity)+"/"+string(tt.other), func(t *testing.T) {
+			if v := tt.severity.AtLeast(tt.other); v != tt.want {
+				t.Fatalf("got %v, want %v", v, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRules(t *testing.T) {
+	rules, err := ReadRules(strings.NewReader(`
+rules:
+- name: application-has-components
+  description: applications must have components
+  kind: Application
+  severity: warning
+  expression: has(application.components)
+`))
+	test.AssertNoError(t, err)
+
+	want := []Rule{
+		{
+			Name:        "application-has-components",
+			Description: "applications must have components",
+			Kind:        KindApplication,
+			Severity:    SeverityWarning,
+			Expression:  "has(application.components)",
+		},
+	}
+	if diff := cmp.Diff(want, rules); diff != "" {
+		t.Fatalf("failed to read rules:\n%s", diff)
+	}
+}
+
+func TestReadRules_unknown_field(t *testing.T) {
+	_, err := ReadRules(strings.NewReader(`
+rules:
+- name: application-has-components
+  expresion: has(application.components)
+`))
+	test.AssertErrorMatch(t, `failed to parse rules: .*unknown field "expresion"`, err)
+}
+
+func TestEnabledBuiltinRules(t *testing.T) {
+	rules, err := EnabledBuiltinRules("application-delivered-by-flux", "branch-after-tag")
+	test.AssertNoError(t, err)
+
+	want := []Rule{BuiltinRules[1], BuiltinRules[2]}
+	if diff := cmp.Diff(want, rules); diff != "" {
+		t.Fatalf("failed to disable rules:\n%s", diff)
+	}
+}
+
+func TestEnabledBuiltinRules_unknown_rule(t *testing.T) {
+	_, err := EnabledBuiltinRules("application-delivered-by-fluxcd")
+	test.AssertErrorMatch(t, `unknown rule "application-delivered-by-fluxcd" to disable`, err)
+}
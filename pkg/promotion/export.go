@@ -0,0 +1,81 @@
+// Package promotion exports discovered Pipelines as manifests for promotion
+// tools.
+package promotion
+
+import (
+	"bytes"
+	"fmt"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+const (
+	// WeaveGitOpsFormat exports Weave GitOps Pipelines.
+	WeaveGitOpsFormat = "weave-gitops"
+
+	// KargoFormat exports Kargo Projects, Warehouses and Stages.
+	KargoFormat = "kargo"
+)
+
+// Formats is the set of supported export formats.
+var Formats = []string{WeaveGitOpsFormat, KargoFormat}
+
+// Pipeline is a discovered Pipeline with the stages that deliver it.
+type Pipeline struct {
+	Name   string
+	Stages []pipelines.Stage
+}
+
+// Export renders the Pipelines as a multi-document YAML stream in the
+// requested format.
+//
+// The repositories are used to find the source of each stage.
+func Export(format string, pls []Pipeline, repositories []sourcev1.GitRepository) (string, error) {
+	var objs []any
+	for _, pl := range pls {
+		var (
+			exported []any
+			err      error
+		)
+		switch format {
+		case WeaveGitOpsFormat:
+			exported, err = weaveGitOpsPipeline(pl)
+		case KargoFormat:
+			exported, err = kargoPipeline(pl, repositories)
+		default:
+			return "", fmt.Errorf("unknown export format %q, must be one of %v", format, Formats)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to export pipeline %q: %w", pl.Name, err)
+		}
+		objs = append(objs, exported...)
+	}
+
+	var b bytes.Buffer
+	for i, obj := range objs {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		b.Write(data)
+	}
+	return b.String(), nil
+}
+
+// typeMeta and objectMeta are the subset of the Kubernetes metadata that is
+// exported.
+type typeMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+type objectMeta struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
@@ -0,0 +1,103 @@
+package promotion
+
+import (
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+func TestExport_errors(t *testing.T) {
+	exportTests := []struct {
+		name    string
+		format  string
+		modify  func(*Pipeline)
+		wantErr string
+	}{
+		{
+			name:    "unknown format",
+			format:  "spinnaker",
+			wantErr: `unknown export format "spinnaker"`,
+		},
+		{
+			name:   "weave-gitops with different Kustomization names",
+			format: WeaveGitOpsFormat,
+			modify: func(pl *Pipeline) {
+				pl.Stages[1].Kustomizations[0].Name = "sockshop-prod"
+			},
+			wantErr: `stage "prod" is delivered by Kustomization prod/sockshop-prod, Weave GitOps requires the same name "sockshop"`,
+		},
+		{
+			name:   "stage with no Kustomizations",
+			format: WeaveGitOpsFormat,
+			modify: func(pl *Pipeline) {
+				pl.Stages[1].Kustomizations = nil
+			},
+			wantErr: `no Kustomizations found for stage "prod"`,
+		},
+		{
+			name:   "kargo with no source repository",
+			format: KargoFormat,
+			modify: func(pl *Pipeline) {
+				pl.Stages[0].Kustomizations[0].Spec.SourceRef.Name = "unknown"
+			},
+			wantErr: `no GitRepository found for Kustomization dev/sockshop in stage "dev"`,
+		},
+	}
+
+	for _, tt := range exportTests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := makePipeline()
+			if tt.modify != nil {
+				tt.modify(&pl)
+			}
+
+			_, err := Export(tt.format, []Pipeline{pl}, makeRepositories())
+			test.AssertErrorMatch(t, tt.wantErr, err)
+		})
+	}
+}
+
+func makePipeline() Pipeline {
+	return Pipeline{
+		Name: "sockshop",
+		Stages: []pipelines.Stage{
+			{Name: "dev", Kustomizations: []kustomizev1.Kustomization{makeKustomization("sockshop", "dev", "sockshop-dev")}},
+			{Name: "prod", After: "dev", Kustomizations: []kustomizev1.Kustomization{makeKustomization("sockshop", "prod", "sockshop-prod")}},
+		},
+	}
+}
+
+func makeKustomization(name, namespace, source string) kustomizev1.Kustomization {
+	return kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: kustomizev1.KustomizationSpec{
+			SourceRef: kustomizev1.CrossNamespaceSourceReference{
+				Kind:      sourcev1.GitRepositoryKind,
+				Name:      source,
+				Namespace: "flux-system",
+			},
+		},
+	}
+}
+
+func makeRepositories() []sourcev1.GitRepository {
+	return []sourcev1.GitRepository{
+		makeRepository("sockshop-dev", "main"),
+		makeRepository("sockshop-prod", "production"),
+	}
+}
+
+func makeRepository(name, branch string) sourcev1.GitRepository {
+	return sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "flux-system"},
+		Spec: sourcev1.GitRepositorySpec{
+			URL:       "https://github.com/example/sockshop.git",
+			Reference: &sourcev1.GitRepositoryRef{Branch: branch},
+		},
+	}
+}
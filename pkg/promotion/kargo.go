@@ -0,0 +1,142 @@
+package promotion
+
+import (
+	"fmt"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+const kargoAPIVersion = "kargo.akuity.io/v1alpha1"
+
+type kargoProject struct {
+	typeMeta `json:",inline"`
+	Metadata objectMeta `json:"metadata"`
+}
+
+type kargoWarehouse struct {
+	typeMeta `json:",inline"`
+	Metadata objectMeta         `json:"metadata"`
+	Spec     kargoWarehouseSpec `json:"spec"`
+}
+
+type kargoWarehouseSpec struct {
+	Subscriptions []kargoRepoSubscription `json:"subscriptions"`
+}
+
+type kargoRepoSubscription struct {
+	Git kargoGitSubscription `json:"git"`
+}
+
+type kargoGitSubscription struct {
+	RepoURL string `json:"repoURL"`
+	Branch  string `json:"branch,omitempty"`
+}
+
+type kargoStage struct {
+	typeMeta `json:",inline"`
+	Metadata objectMeta     `json:"metadata"`
+	Spec     kargoStageSpec `json:"spec"`
+}
+
+type kargoStageSpec struct {
+	Subscriptions       kargoSubscriptions       `json:"subscriptions"`
+	PromotionMechanisms kargoPromotionMechanisms `json:"promotionMechanisms"`
+}
+
+type kargoSubscriptions struct {
+	Warehouse      string                `json:"warehouse,omitempty"`
+	UpstreamStages []kargoStageReference `json:"upstreamStages,omitempty"`
+}
+
+type kargoStageReference struct {
+	Name string `json:"name"`
+}
+
+type kargoPromotionMechanisms struct {
+	GitRepoUpdates []kargoGitRepoUpdate `json:"gitRepoUpdates,omitempty"`
+}
+
+type kargoGitRepoUpdate struct {
+	RepoURL     string `json:"repoURL"`
+	WriteBranch string `json:"writeBranch,omitempty"`
+}
+
+// kargoPipeline converts the Pipeline to a Kargo Project with a Warehouse
+// that subscribes to the sources of the first stages, and a Stage for each
+// stage that is promoted from the stage that it is after.
+//
+// Kargo Projects are namespaces, so the Project is named for the Pipeline.
+func kargoPipeline(pl Pipeline, repositories []sourcev1.GitRepository) ([]any, error) {
+	if len(pl.Stages) == 0 {
+		return nil, fmt.Errorf("no stages found")
+	}
+
+	var stages []any
+	warehouse := kargoWarehouse{
+		typeMeta: typeMeta{APIVersion: kargoAPIVersion, Kind: "Warehouse"},
+		Metadata: objectMeta{Name: pl.Name, Namespace: pl.Name},
+	}
+	subscribed := map[kargoGitSubscription]bool{}
+	for _, stage := range pl.Stages {
+		updates, err := kargoGitRepoUpdates(stage, repositories)
+		if err != nil {
+			return nil, err
+		}
+
+		s := kargoStage{
+			typeMeta: typeMeta{APIVersion: kargoAPIVersion, Kind: "Stage"},
+			Metadata: objectMeta{Name: stage.Name, Namespace: pl.Name},
+			Spec: kargoStageSpec{
+				PromotionMechanisms: kargoPromotionMechanisms{GitRepoUpdates: updates},
+			},
+		}
+		if stage.After == "" {
+			for _, v := range updates {
+				sub := kargoGitSubscription{RepoURL: v.RepoURL, Branch: v.WriteBranch}
+				if !subscribed[sub] {
+					subscribed[sub] = true
+					warehouse.Spec.Subscriptions = append(warehouse.Spec.Subscriptions, kargoRepoSubscription{Git: sub})
+				}
+			}
+			s.Spec.Subscriptions.Warehouse = warehouse.Metadata.Name
+		} else {
+			s.Spec.Subscriptions.UpstreamStages = []kargoStageReference{{Name: stage.After}}
+		}
+		stages = append(stages, s)
+	}
+
+	project := kargoProject{
+		typeMeta: typeMeta{APIVersion: kargoAPIVersion, Kind: "Project"},
+		Metadata: objectMeta{Name: pl.Name},
+	}
+
+	return append([]any{project, warehouse}, stages...), nil
+}
+
+// kargoGitRepoUpdates returns an update for each of the repositories that are
+// the source of the Kustomizations in the stage.
+func kargoGitRepoUpdates(stage pipelines.Stage, repositories []sourcev1.GitRepository) ([]kargoGitRepoUpdate, error) {
+	if len(stage.Kustomizations) == 0 {
+		return nil, fmt.Errorf("no Kustomizations found for stage %q", stage.Name)
+	}
+
+	var updates []kargoGitRepoUpdate
+	seen := map[kargoGitRepoUpdate]bool{}
+	for _, k := range stage.Kustomizations {
+		repo := pipelines.SourceRepository(k, repositories)
+		if repo == nil {
+			return nil, fmt.Errorf("no GitRepository found for Kustomization %s/%s in stage %q", k.Namespace, k.Name, stage.Name)
+		}
+		update := kargoGitRepoUpdate{RepoURL: repo.Spec.URL}
+		if repo.Spec.Reference != nil {
+			update.WriteBranch = repo.Spec.Reference.Branch
+		}
+		if !seen[update] {
+			seen[update] = true
+			updates = append(updates, update)
+		}
+	}
+	return updates, nil
+}
@@ -0,0 +1,137 @@
+package promotion
+
+import (
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+func TestExport_kargo(t *testing.T) {
+	out, err := Export(KargoFormat, []Pipeline{makePipeline()}, makeRepositories())
+	test.AssertNoError(t, err)
+
+	want := `apiVersion: kargo.akuity.io/v1alpha1
+kind: Project
+metadata:
+  name: sockshop
+---
+apiVersion: kargo.akuity.io/v1alpha1
+kind: Warehouse
+metadata:
+  name: sockshop
+  namespace: sockshop
+spec:
+  subscriptions:
+  - git:
+      branch: main
+      repoURL: https://github.com/example/sockshop.git
+---
+apiVersion: kargo.akuity.io/v1alpha1
+kind: Stage
+metadata:
+  name: dev
+  namespace: sockshop
+spec:
+  promotionMechanisms:
+    gitRepoUpdates:
+    - repoURL: https://github.com/example/sockshop.git
+      writeBranch: main
+  subscriptions:
+    warehouse: sockshop
+---
+apiVersion: kargo.akuity.io/v1alpha1
+kind: Stage
+metadata:
+  name: prod
+  namespace: sockshop
+spec:
+  promotionMechanisms:
+    gitRepoUpdates:
+    - repoURL: https://github.com/example/sockshop.git
+      writeBranch: production
+  subscriptions:
+    upstreamStages:
+    - name: dev
+`
+	if diff := cmp.Diff(want, out); diff != "" {
+		t.Fatalf("failed to export:\n%s", diff)
+	}
+}
+
+func TestExport_kargo_branching_pipeline(t *testing.T) {
+	pl := Pipeline{
+		Name: "sockshop",
+		Stages: []pipelines.Stage{
+			{Name: "dev", Kustomizations: []kustomizev1.Kustomization{makeKustomization("sockshop", "dev", "sockshop-dev")}},
+			{Name: "staging-a", After: "dev", Kustomizations: []kustomizev1.Kustomization{makeKustomization("sockshop", "staging-a", "sockshop-dev")}},
+			{Name: "staging-b", After: "dev", Kustomizations: []kustomizev1.Kustomization{makeKustomization("sockshop", "staging-b", "sockshop-dev")}},
+		},
+	}
+	out, err := Export(KargoFormat, []Pipeline{pl}, makeRepositories())
+	test.AssertNoError(t, err)
+
+	want := `apiVersion: kargo.akuity.io/v1alpha1
+kind: Project
+metadata:
+  name: sockshop
+---
+apiVersion: kargo.akuity.io/v1alpha1
+kind: Warehouse
+metadata:
+  name: sockshop
+  namespace: sockshop
+spec:
+  subscriptions:
+  - git:
+      branch: main
+      repoURL: https://github.com/example/sockshop.git
+---
+apiVersion: kargo.akuity.io/v1alpha1
+kind: Stage
+metadata:
+  name: dev
+  namespace: sockshop
+spec:
+  promotionMechanisms:
+    gitRepoUpdates:
+    - repoURL: https://github.com/example/sockshop.git
+      writeBranch: main
+  subscriptions:
+    warehouse: sockshop
+---
+apiVersion: kargo.akuity.io/v1alpha1
+kind: Stage
+metadata:
+  name: staging-a
+  namespace: sockshop
+spec:
+  promotionMechanisms:
+    gitRepoUpdates:
+    - repoURL: https://github.com/example/sockshop.git
+      writeBranch: main
+  subscriptions:
+    upstreamStages:
+    - name: dev
+---
+apiVersion: kargo.akuity.io/v1alpha1
+kind: Stage
+metadata:
+  name: staging-b
+  namespace: sockshop
+spec:
+  promotionMechanisms:
+    gitRepoUpdates:
+    - repoURL: https://github.com/example/sockshop.git
+      writeBranch: main
+  subscriptions:
+    upstreamStages:
+    - name: dev
+`
+	if diff := cmp.Diff(want, out); diff != "" {
+		t.Fatalf("failed to export:\n%s", diff)
+	}
+}
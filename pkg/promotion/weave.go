@@ -0,0 +1,78 @@
+package promotion
+
+import (
+	"fmt"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+)
+
+const weavePipelinesAPIVersion = "pipelines.weave.works/v1alpha1"
+
+type weavePipeline struct {
+	typeMeta `json:",inline"`
+	Metadata objectMeta        `json:"metadata"`
+	Spec     weavePipelineSpec `json:"spec"`
+}
+
+type weavePipelineSpec struct {
+	AppRef       weaveAppRef        `json:"appRef"`
+	Environments []weaveEnvironment `json:"environments"`
+}
+
+type weaveAppRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+type weaveEnvironment struct {
+	Name    string        `json:"name"`
+	Targets []weaveTarget `json:"targets"`
+}
+
+type weaveTarget struct {
+	Namespace string `json:"namespace"`
+}
+
+// weaveGitOpsPipeline converts the Pipeline to a Weave GitOps Pipeline.
+//
+// Weave GitOps promotes a single application through the environments, so the
+// Kustomizations in every stage must have the same name, and each
+// Kustomization's namespace is a target.
+func weaveGitOpsPipeline(pl Pipeline) ([]any, error) {
+	if len(pl.Stages) == 0 || len(pl.Stages[0].Kustomizations) == 0 {
+		return nil, fmt.Errorf("no Kustomizations found for the first stage")
+	}
+	app := pl.Stages[0].Kustomizations[0]
+
+	var environments []weaveEnvironment
+	for _, stage := range pl.Stages {
+		if len(stage.Kustomizations) == 0 {
+			return nil, fmt.Errorf("no Kustomizations found for stage %q", stage.Name)
+		}
+		env := weaveEnvironment{Name: stage.Name}
+		for _, k := range stage.Kustomizations {
+			if k.Name != app.Name {
+				return nil, fmt.Errorf("stage %q is delivered by Kustomization %s/%s, Weave GitOps requires the same name %q in every stage",
+					stage.Name, k.Namespace, k.Name, app.Name)
+			}
+			env.Targets = append(env.Targets, weaveTarget{Namespace: k.Namespace})
+		}
+		environments = append(environments, env)
+	}
+
+	return []any{
+		weavePipeline{
+			typeMeta: typeMeta{APIVersion: weavePipelinesAPIVersion, Kind: "Pipeline"},
+			Metadata: objectMeta{Name: pl.Name, Namespace: app.Namespace},
+			Spec: weavePipelineSpec{
+				AppRef: weaveAppRef{
+					APIVersion: kustomizev1.GroupVersion.String(),
+					Kind:       kustomizev1.KustomizationKind,
+					Name:       app.Name,
+				},
+				Environments: environments,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,36 @@
+package promotion
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+func TestExport_weave_gitops(t *testing.T) {
+	out, err := Export(WeaveGitOpsFormat, []Pipeline{makePipeline()}, nil)
+	test.AssertNoError(t, err)
+
+	want := `apiVersion: pipelines.weave.works/v1alpha1
+kind: Pipeline
+metadata:
+  name: sockshop
+  namespace: dev
+spec:
+  appRef:
+    apiVersion: kustomize.toolkit.fluxcd.io/v1beta2
+    kind: Kustomization
+    name: sockshop
+  environments:
+  - name: dev
+    targets:
+    - namespace: dev
+  - name: prod
+    targets:
+    - namespace: prod
+`
+	if diff := cmp.Diff(want, out); diff != "" {
+		t.Fatalf("failed to export:\n%s", diff)
+	}
+}
@@ -0,0 +1,139 @@
+// Package rbac generates the least-privilege RBAC resources that the scanner
+// needs to list the kinds of resource that it scans.
+package rbac
+
+import (
+	"bytes"
+	"io"
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// Verbs are the verbs that are granted on each of the scanned resources.
+var Verbs = []string{"list", "watch"}
+
+// Options configures the generated resources.
+type Options struct {
+	// Name is the name of the roles and bindings.
+	Name string
+
+	// ServiceAccount is the ServiceAccount that the roles are bound to.
+	ServiceAccount types.NamespacedName
+
+	// Namespaces restricts the access to a Role in each of the namespaces,
+	// if there are no namespaces, a ClusterRole grants access in all
+	// namespaces.
+	Namespaces []string
+}
+
+// Rules returns a rule for each of the API groups of the kinds, that grants the
+// Verbs on the resources of the kinds in the group.
+//
+// The resources are guessed from the kinds, which is correct for all the
+// built-in kinds and the Flux and Argo CD kinds.
+func Rules(kinds []schema.GroupKind) []rbacv1.PolicyRule {
+	resources := map[string]map[string]bool{}
+	for _, gk := range kinds {
+		plural, _ := meta.UnsafeGuessKindToResource(gk.WithVersion(""))
+		if resources[gk.Group] == nil {
+			resources[gk.Group] = map[string]bool{}
+		}
+		resources[gk.Group][plural.Resource] = true
+	}
+
+	groups := make([]string, 0, len(resources))
+	for group := range resources {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	rules := make([]rbacv1.PolicyRule, len(groups))
+	for i, group := range groups {
+		rules[i] = rbacv1.PolicyRule{
+			APIGroups: []string{group},
+			Resources: sortedKeys(resources[group]),
+			Verbs:     append([]string(nil), Verbs...),
+		}
+	}
+	return rules
+}
+
+// Resources returns the roles that grant the Rules for the kinds, and the
+// bindings of the roles to the ServiceAccount.
+func Resources(kinds []schema.GroupKind, opts Options) []client.Object {
+	rules := Rules(kinds)
+	subjects := []rbacv1.Subject{
+		{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      opts.ServiceAccount.Name,
+			Namespace: opts.ServiceAccount.Namespace,
+		},
+	}
+
+	if len(opts.Namespaces) == 0 {
+		return []client.Object{
+			&rbacv1.ClusterRole{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+				ObjectMeta: metav1.ObjectMeta{Name: opts.Name},
+				Rules:      rules,
+			},
+			&rbacv1.ClusterRoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: opts.Name},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: opts.Name},
+				Subjects:   subjects,
+			},
+		}
+	}
+
+	var res []client.Object
+	for _, ns := range opts.Namespaces {
+		res = append(res,
+			&rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+				ObjectMeta: metav1.ObjectMeta{Name: opts.Name, Namespace: ns},
+				Rules:      rules,
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: opts.Name, Namespace: ns},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: opts.Name},
+				Subjects:   subjects,
+			},
+		)
+	}
+	return res
+}
+
+// Write writes the resources as multi-document YAML.
+func Write(w io.Writer, objs []client.Object) error {
+	var b bytes.Buffer
+	for i, obj := range objs {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func sortedKeys(m map[string]bool) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,147 @@
+package rbac
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+var testKinds = []schema.GroupKind{
+	{Group: "apps", Kind: "StatefulSet"},
+	{Group: "apps", Kind: "Deployment"},
+	{Group: "kustomize.toolkit.fluxcd.io", Kind: "Kustomization"},
+	{Group: "argoproj.io", Kind: "Application"},
+	{Group: "argoproj.io", Kind: "ApplicationSet"},
+	{Group: "", Kind: "Service"},
+}
+
+var testServiceAccount = types.NamespacedName{Name: "apps-scanner", Namespace: "apps-scanner"}
+
+func TestRules(t *testing.T) {
+	want := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"services"}, Verbs: []string{"list", "watch"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments", "statefulsets"}, Verbs: []string{"list", "watch"}},
+		{APIGroups: []string{"argoproj.io"}, Resources: []string{"applications", "applicationsets"}, Verbs: []string{"list", "watch"}},
+		{APIGroups: []string{"kustomize.toolkit.fluxcd.io"}, Resources: []string{"kustomizations"}, Verbs: []string{"list", "watch"}},
+	}
+	if diff := cmp.Diff(want, Rules(testKinds)); diff != "" {
+		t.Fatalf("failed to generate rules:\n%s", diff)
+	}
+}
+
+func TestResources(t *testing.T) {
+	kinds := []schema.GroupKind{{Group: "apps", Kind: "Deployment"}}
+	rules := []rbacv1.PolicyRule{
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"list", "watch"}},
+	}
+	subjects := []rbacv1.Subject{{Kind: "ServiceAccount", Name: "apps-scanner", Namespace: "apps-scanner"}}
+
+	resourceTests := []struct {
+		name       string
+		namespaces []string
+		want       []client.Object
+	}{
+		{
+			name: "all namespaces",
+			want: []client.Object{
+				&rbacv1.ClusterRole{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+					ObjectMeta: metav1.ObjectMeta{Name: "scanner"},
+					Rules:      rules,
+				},
+				&rbacv1.ClusterRoleBinding{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+					ObjectMeta: metav1.ObjectMeta{Name: "scanner"},
+					RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "scanner"},
+					Subjects:   subjects,
+				},
+			},
+		},
+		{
+			name:       "restricted to namespaces",
+			namespaces: []string{"dev", "prod"},
+			want: []client.Object{
+				&rbacv1.Role{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+					ObjectMeta: metav1.ObjectMeta{Name: "scanner", Namespace: "dev"},
+					Rules:      rules,
+				},
+				&rbacv1.RoleBinding{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+					ObjectMeta: metav1.ObjectMeta{Name: "scanner", Namespace: "dev"},
+					RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: "scanner"},
+					Subjects:   subjects,
+				},
+				&rbacv1.Role{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+					ObjectMeta: metav1.ObjectMeta{Name: "scanner", Namespace: "prod"},
+					Rules:      rules,
+				},
+				&rbacv1.RoleBinding{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+					ObjectMeta: metav1.ObjectMeta{Name: "scanner", Namespace: "prod"},
+					RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: "scanner"},
+					Subjects:   subjects,
+				},
+			},
+		},
+	}
+
+	for _, tt := range resourceTests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := Resources(kinds, Options{Name: "scanner", ServiceAccount: testServiceAccount, Namespaces: tt.namespaces})
+
+			if diff := cmp.Diff(tt.want, objs); diff != "" {
+				t.Fatalf("failed to generate resources:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	objs := Resources([]schema.GroupKind{{Group: "apps", Kind: "Deployment"}},
+		Options{Name: "scanner", ServiceAccount: testServiceAccount})
+
+	var b bytes.Buffer
+	test.AssertNoError(t, Write(&b, objs))
+
+	want := `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  creationTimestamp: null
+  name: scanner
+rules:
+- apiGroups:
+  - apps
+  resources:
+  - deployments
+  verbs:
+  - list
+  - watch
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  creationTimestamp: null
+  name: scanner
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: scanner
+subjects:
+- kind: ServiceAccount
+  name: apps-scanner
+  namespace: apps-scanner
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Fatalf("failed to write resources:\n%s", diff)
+	}
+}
@@ -0,0 +1,98 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+)
+
+const (
+	nodeWidth    = 180
+	nodeHeight   = 28
+	columnGap    = 80
+	rowGap       = 16
+	graphPadding = 10
+)
+
+// applicationsSVG lays out the Applications as an inline SVG graph.
+//
+// Applications are placed in columns by their depth in the parent hierarchy,
+// with top-level Applications on the left, and lines are drawn from parents to
+// their children.
+func applicationsSVG(apps []applications.Application) template.HTML {
+	if len(apps) == 0 {
+		return ""
+	}
+	parents := map[string][]string{}
+	for _, app := range apps {
+		parents[app.Name] = parentNames(app)
+	}
+	depths := map[string]int{}
+	for _, app := range apps {
+		applicationDepth(app.Name, parents, depths, map[string]bool{})
+	}
+
+	type position struct{ x, y int }
+	positions := map[string]position{}
+	rows := map[int]int{}
+	maxDepth, maxRows := 0, 0
+	for _, app := range apps {
+		d := depths[app.Name]
+		positions[app.Name] = position{
+			x: graphPadding + d*(nodeWidth+columnGap),
+			y: graphPadding + rows[d]*(nodeHeight+rowGap),
+		}
+		rows[d]++
+		maxDepth = max(maxDepth, d)
+		maxRows = max(maxRows, rows[d])
+	}
+
+	width := 2*graphPadding + (maxDepth+1)*nodeWidth + maxDepth*columnGap
+	height := 2*graphPadding + maxRows*nodeHeight + (maxRows-1)*rowGap
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	for _, app := range apps {
+		child := positions[app.Name]
+		for _, p := range app.Parents {
+			parent, ok := positions[p.Name]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&b, `<line class="edge" x1="%d" y1="%d" x2="%d" y2="%d"/>`,
+				parent.x+nodeWidth, parent.y+nodeHeight/2, child.x, child.y+nodeHeight/2)
+		}
+	}
+	for _, app := range apps {
+		pos := positions[app.Name]
+		name := html.EscapeString(app.Name)
+		fmt.Fprintf(&b, `<g class="node" data-name="%s"><rect x="%d" y="%d" width="%d" height="%d" rx="4"/><text x="%d" y="%d">%s</text></g>`,
+			name, pos.x, pos.y, nodeWidth, nodeHeight, pos.x+8, pos.y+nodeHeight/2+5, name)
+	}
+	b.WriteString("</svg>")
+
+	return template.HTML(b.String())
+}
+
+// applicationDepth calculates the depth of the named Application in the
+// hierarchy of parents, recording the depths as they are calculated.
+func applicationDepth(name string, parents map[string][]string, depths map[string]int, seen map[string]bool) int {
+	if d, ok := depths[name]; ok {
+		return d
+	}
+	// Guard against cycles in the parent relationships.
+	if seen[name] {
+		return 0
+	}
+	seen[name] = true
+
+	d := 0
+	for _, p := range parents[name] {
+		d = max(d, applicationDepth(p, parents, depths, seen)+1)
+	}
+	depths[name] = d
+	return d
+}
@@ -0,0 +1,34 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+)
+
+func TestApplicationsSVG(t *testing.T) {
+	svg := applicationsSVG([]applications.Application{
+		{Name: "backend", Parents: []applications.Application{{Name: "frontend"}}},
+		{Name: "billing-system"},
+		{Name: "frontend", Parents: []applications.Application{{Name: "billing-system"}}},
+	})
+
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="720" height="48" viewBox="0 0 720 48">` +
+		`<line class="edge" x1="450" y1="24" x2="530" y2="24"/>` +
+		`<line class="edge" x1="190" y1="24" x2="270" y2="24"/>` +
+		`<g class="node" data-name="backend"><rect x="530" y="10" width="180" height="28" rx="4"/><text x="538" y="29">backend</text></g>` +
+		`<g class="node" data-name="billing-system"><rect x="10" y="10" width="180" height="28" rx="4"/><text x="18" y="29">billing-system</text></g>` +
+		`<g class="node" data-name="frontend"><rect x="270" y="10" width="180" height="28" rx="4"/><text x="278" y="29">frontend</text></g>` +
+		`</svg>`
+	if diff := cmp.Diff(want, string(svg)); diff != "" {
+		t.Fatalf("failed to layout applications:\n%s", diff)
+	}
+}
+
+func TestApplicationsSVG_no_applications(t *testing.T) {
+	if svg := applicationsSVG(nil); svg != "" {
+		t.Fatalf("got %q, want empty graph", svg)
+	}
+}
@@ -0,0 +1,179 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+)
+
+var (
+	//go:embed report.html.tmpl
+	reportTemplate string
+
+	//go:embed report.css
+	reportCSS string
+
+	//go:embed report.js
+	reportJS string
+
+	htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(reportTemplate))
+)
+
+// WriteHTML writes a self-contained HTML report of the Inventory.
+//
+// All styles and scripts are embedded in the report so that it can be
+// published without access to external resources.
+func WriteHTML(w io.Writer, inv inventory.Inventory, generated time.Time) error {
+	if err := htmlTemplate.Execute(w, newReportData(inv, generated)); err != nil {
+		return fmt.Errorf("failed to generate HTML report: %w", err)
+	}
+	return nil
+}
+
+type reportData struct {
+	Generated    string
+	CSS          template.CSS
+	JS           template.JS
+	Graph        template.HTML
+	Applications []applicationRow
+	Components   []memberRow
+	Instances    []memberRow
+	Pipelines    []pipelineRow
+	Repositories []repositoryRow
+	Coverage     []coverageRow
+}
+
+type applicationRow struct {
+	Name           string
+	Parents        []string
+	Instances      []string
+	Components     []string
+	Kustomizations []string
+}
+
+// memberRow is used for both components and instances, which are listed with
+// the Application they belong to.
+type memberRow struct {
+	Name        string
+	Application string
+}
+
+type pipelineRow struct {
+	Name         string
+	Environments []string
+}
+
+type repositoryRow struct {
+	URL  string
+	Name string
+	Ref  string
+}
+
+// coverageRow is a list of resources that could not be scanned.
+type coverageRow struct {
+	Kind      string
+	Namespace string
+	Status    string
+	Reason    string
+}
+
+func newReportData(inv inventory.Inventory, generated time.Time) reportData {
+	data := reportData{
+		Generated: generated.UTC().Format(time.RFC3339),
+		CSS:       template.CSS(reportCSS),
+		JS:        template.JS(reportJS),
+		Graph:     applicationsSVG(inv.Applications),
+	}
+
+	for _, app := range inv.Applications {
+		row := applicationRow{
+			Name:       app.Name,
+			Parents:    parentNames(app),
+			Instances:  nonEmpty(app.Instances),
+			Components: nonEmpty(app.Components),
+		}
+		for _, k := range app.Kustomizations {
+			row.Kustomizations = append(row.Kustomizations, k.String())
+		}
+		data.Applications = append(data.Applications, row)
+
+		for _, c := range row.Components {
+			data.Components = append(data.Components, memberRow{Name: c, Application: app.Name})
+		}
+		for _, i := range row.Instances {
+			data.Instances = append(data.Instances, memberRow{Name: i, Application: app.Name})
+		}
+	}
+	sortMembers(data.Components)
+	sortMembers(data.Instances)
+
+	for _, p := range inv.Pipelines {
+		data.Pipelines = append(data.Pipelines, pipelineRow{Name: p.Name, Environments: p.Environments})
+	}
+
+	for _, r := range inv.Repositories {
+		for _, ref := range r.Refs {
+			data.Repositories = append(data.Repositories, repositoryRow{
+				URL:  r.URL,
+				Name: ref.NamespacedName.String(),
+				Ref:  flux.DescribeRef(ref.Ref),
+			})
+		}
+	}
+
+	if c := inv.Coverage; c != nil {
+		data.Coverage = append(data.Coverage, coverageRows("skipped", c.Skipped)...)
+		data.Coverage = append(data.Coverage, coverageRows("failed", c.Failed)...)
+	}
+
+	return data
+}
+
+func coverageRows(status string, gaps []inventory.Gap) []coverageRow {
+	var res []coverageRow
+	for _, v := range gaps {
+		ns := v.Namespace
+		if ns == "" {
+			ns = "all namespaces"
+		}
+		res = append(res, coverageRow{Kind: v.Kind, Namespace: ns, Status: status, Reason: v.Reason})
+	}
+	return res
+}
+
+func parentNames(app applications.Application) []string {
+	var res []string
+	for _, p := range app.Parents {
+		res = append(res, p.Name)
+	}
+	return res
+}
+
+func nonEmpty(s []string) []string {
+	var res []string
+	for _, v := range s {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func sortMembers(rows []memberRow) {
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Name == rows[j].Name {
+			return rows[i].Application < rows[j].Application
+		}
+		return rows[i].Name < rows[j].Name
+	})
+}
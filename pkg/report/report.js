@@ -0,0 +1,23 @@
+document.addEventListener("DOMContentLoaded", function () {
+  document.querySelectorAll("input.search[data-table]").forEach(function (input) {
+    var table = document.getElementById(input.dataset.table);
+    input.addEventListener("input", function () {
+      var term = input.value.toLowerCase();
+      table.querySelectorAll("tbody tr").forEach(function (row) {
+        var match = row.textContent.toLowerCase().indexOf(term) !== -1;
+        row.classList.toggle("hidden", !match);
+      });
+    });
+  });
+
+  var graphSearch = document.getElementById("graph-search");
+  if (graphSearch) {
+    graphSearch.addEventListener("input", function () {
+      var term = graphSearch.value.toLowerCase();
+      document.querySelectorAll(".graph .node").forEach(function (node) {
+        var match = term !== "" && node.dataset.name.toLowerCase().indexOf(term) !== -1;
+        node.classList.toggle("highlight", match);
+      });
+    });
+  }
+});
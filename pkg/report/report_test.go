@@ -0,0 +1,95 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+func TestWriteHTML(t *testing.T) {
+	inv := inventory.Inventory{
+		Applications: []applications.Application{
+			{
+				Name:           "frontend",
+				Instances:      []string{"frontend-dev"},
+				Components:     []string{"web"},
+				Parents:        []applications.Application{{Name: "billing-system"}},
+				Kustomizations: []types.NamespacedName{{Name: "repo-main", Namespace: "flux-system"}},
+			},
+			{Name: "billing-system"},
+		},
+		Pipelines: []pipelines.Pipeline{
+			{Name: "billing-pipeline", Environments: []string{"staging", "production"}},
+		},
+		Repositories: []flux.Repository{
+			{
+				URL: "https://github.com/example/repo.git",
+				Refs: []flux.RepositoryRef{
+					{NamespacedName: types.NamespacedName{Name: "repo", Namespace: "flux-system"}, Ref: sourcev1.GitRepositoryRef{Branch: "main"}},
+				},
+			},
+		},
+	}
+
+	var b bytes.Buffer
+	test.AssertNoError(t, WriteHTML(&b, inv, time.Date(2026, time.September, 1, 12, 0, 0, 0, time.UTC)))
+
+	report := b.String()
+	for _, want := range []string{
+		"Generated 2026-09-01T12:00:00Z",
+		"<tr><td>frontend</td><td>billing-system</td><td>web</td><td>frontend-dev</td><td>flux-system/repo-main</td></tr>",
+		"<tr><td>web</td><td>frontend</td></tr>",
+		"<tr><td>frontend-dev</td><td>frontend</td></tr>",
+		"<tr><td>billing-pipeline</td><td>staging → production</td></tr>",
+		"<tr><td>https://github.com/example/repo.git</td><td>flux-system/repo</td><td>branch main</td></tr>",
+		`<g class="node" data-name="frontend">`,
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+
+	if strings.Contains(report, `id="coverage"`) {
+		t.Error("report of a complete inventory contains a coverage section")
+	}
+
+	// The report must not load anything from external locations.
+	for _, external := range []string{"<link", "src=", "@import"} {
+		if strings.Contains(report, external) {
+			t.Errorf("report contains external reference %q", external)
+		}
+	}
+}
+
+func TestWriteHTML_incomplete(t *testing.T) {
+	inv := inventory.Inventory{
+		Coverage: &inventory.Coverage{
+			Skipped: []inventory.Gap{{Kind: "Kustomization.kustomize.toolkit.fluxcd.io", Namespace: "prod", Reason: "access denied"}},
+			Failed:  []inventory.Gap{{Kind: "Deployment.apps", Reason: "context deadline exceeded"}},
+		},
+	}
+
+	var b bytes.Buffer
+	test.AssertNoError(t, WriteHTML(&b, inv, time.Date(2026, time.September, 1, 12, 0, 0, 0, time.UTC)))
+
+	report := b.String()
+	for _, want := range []string{
+		`<a href="#coverage">Coverage</a>`,
+		"<tr><td>Kustomization.kustomize.toolkit.fluxcd.io</td><td>prod</td><td>skipped</td><td>access denied</td></tr>",
+		"<tr><td>Deployment.apps</td><td>all namespaces</td><td>failed</td><td>context deadline exceeded</td></tr>",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+)
+
+// ErrForbidden is the error for lists that are skipped because the source
+// reviewed access and listing is not permitted.
+var ErrForbidden = errors.New("access denied")
+
+// ErrForbiddenAllNamespaces is the error for lists in all namespaces that are
+// skipped, listing may still be permitted in some of the namespaces, which are
+// scanned if they are configured.
+var ErrForbiddenAllNamespaces = fmt.Errorf("%w in all namespaces, configure the namespaces to scan", ErrForbidden)
+
+// Failure is a list of a kind of resource that failed.
+type Failure struct {
+	Kind schema.GroupKind
+	// Namespace is empty when listing in all namespaces.
+	Namespace string
+	Err       error
+}
+
+func (f Failure) Error() string {
+	action := "failed to list"
+	if f.Forbidden() {
+		action = "skipped"
+	}
+	if f.Namespace == "" {
+		return fmt.Sprintf("%s %s: %s", action, f.Kind, f.Err)
+	}
+	return fmt.Sprintf("%s %s in %s: %s", action, f.Kind, f.Namespace, f.Err)
+}
+
+// Forbidden returns true if the list was skipped, or failed, because listing
+// is not permitted.
+func (f Failure) Forbidden() bool {
+	return errors.Is(f.Err, ErrForbidden) || apierrors.IsForbidden(f.Err)
+}
+
+func (f Failure) Unwrap() error {
+	return f.Err
+}
+
+// PartialError is returned when some of the lists in a scan fail, the results
+// are discovered from the lists that succeeded and returned with the error.
+type PartialError struct {
+	Failures []Failure
+}
+
+func (e *PartialError) Error() string {
+	msgs := make([]string, len(e.Failures))
+	for i, v := range e.Failures {
+		msgs[i] = v.Error()
+	}
+	return fmt.Sprintf("scan is incomplete: %s", strings.Join(msgs, ", "))
+}
+
+// collectFailures records the failures if the error is a PartialError, and
+// returns any other error.
+func collectFailures(err error, failures *[]Failure) error {
+	var partial *PartialError
+	if errors.As(err, &partial) {
+		*failures = append(*failures, partial.Failures...)
+		return nil
+	}
+	return err
+}
+
+// partialError returns a PartialError with the failures, or nil if nothing
+// failed.
+func partialError(failures []Failure) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	return &PartialError{Failures: failures}
+}
+
+// withoutNoMatches removes the failures for kinds that are not installed.
+func withoutNoMatches(failures []Failure) []Failure {
+	var res []Failure
+	for _, v := range failures {
+		if !meta.IsNoMatchError(v.Err) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// NewCoverage returns the Coverage of a scan with the failures, or nil if
+// nothing failed.
+func NewCoverage(failures []Failure) *inventory.Coverage {
+	if len(failures) == 0 {
+		return nil
+	}
+	var c inventory.Coverage
+	for _, v := range failures {
+		gap := inventory.Gap{Kind: v.Kind.String(), Namespace: v.Namespace, Reason: v.Err.Error()}
+		if v.Forbidden() {
+			c.Skipped = append(c.Skipped, gap)
+		} else {
+			c.Failed = append(c.Failed, gap)
+		}
+	}
+	return &c
+}
@@ -0,0 +1,696 @@
+// Package scanner discovers the Applications, Pipelines and Repositories in
+// clusters.
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"golang.org/x/sync/errgroup"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/argocd"
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/pkg/images"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+	"github.com/gitops-tools/apps-scanner/pkg/source"
+	"github.com/gitops-tools/apps-scanner/pkg/workloads"
+)
+
+// DefaultKinds are the kinds of resource that are scanned for applications
+// if no kinds are configured.
+var DefaultKinds = []schema.GroupKind{{Group: "apps", Kind: "Deployment"}}
+
+// DefaultConcurrency is the number of lists that are made concurrently if no
+// concurrency is configured.
+const DefaultConcurrency = 4
+
+// ErrNoSources is returned when scanning without any sources.
+var ErrNoSources = errors.New("no sources configured")
+
+var (
+	kustomizationKind = kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind)
+	gitRepositoryKind = sourcev1.GroupVersion.WithKind(sourcev1.GitRepositoryKind)
+)
+
+// Scanner discovers the inventory in clusters, or any other source of
+// objects.
+type Scanner struct {
+	sources        []source.Source
+	kinds          []schema.GroupKind
+	namespaces     []string
+	appLabels      applications.Labels
+	pipelineLabels pipelines.Labels
+	environments   *applications.Environments
+	versions       bool
+	progress       io.Writer
+	concurrency    int
+}
+
+// Option configures a Scanner.
+type Option func(*Scanner)
+
+// WithClient adds a client for a cluster to scan, multiple clients can be
+// provided to scan multiple clusters.
+func WithClient(cl client.Client) Option {
+	return WithSource(source.NewCluster(cl))
+}
+
+// WithSource adds a source of objects to scan, multiple sources can be
+// provided and their objects are combined.
+func WithSource(src source.Source) Option {
+	return func(s *Scanner) {
+		s.sources = append(s.sources, src)
+	}
+}
+
+// WithKinds configures the kinds of resource that are scanned for
+// applications.
+func WithKinds(kinds ...schema.GroupKind) Option {
+	return func(s *Scanner) {
+		s.kinds = kinds
+	}
+}
+
+// WithNamespaces restricts scanning to the namespaces, by default all
+// namespaces are scanned.
+func WithNamespaces(namespaces ...string) Option {
+	return func(s *Scanner) {
+		s.namespaces = namespaces
+	}
+}
+
+// WithApplicationLabels configures the labels that applications are
+// discovered from.
+func WithApplicationLabels(l applications.Labels) Option {
+	return func(s *Scanner) {
+		s.appLabels = l
+	}
+}
+
+// WithPipelineLabels configures the labels that pipelines are discovered
+// from.
+func WithPipelineLabels(l pipelines.Labels) Option {
+	return func(s *Scanner) {
+		s.pipelineLabels = l
+	}
+}
+
+// WithEnvironments configures the scanner to identify the environments of the
+// instances of Applications.
+//
+// If environments are identified from pipelines, the Kustomizations in
+// pipelines are listed unless they are provided.
+func WithEnvironments(e applications.Environments) Option {
+	return func(s *Scanner) {
+		s.environments = &e
+	}
+}
+
+// WithVersions configures the scanner to detect the versions of the
+// components of Applications.
+//
+// The versions are detected from the images of workloads, so the complete
+// resources are listed rather than only their metadata.
+func WithVersions() Option {
+	return func(s *Scanner) {
+		s.versions = true
+	}
+}
+
+// WithProgress writes progress messages to the writer, by default they are
+// discarded.
+func WithProgress(w io.Writer) Option {
+	return func(s *Scanner) {
+		s.progress = w
+	}
+}
+
+// WithConcurrency configures the number of lists that are made concurrently,
+// across the kinds, namespaces and sources that are scanned.
+func WithConcurrency(n int) Option {
+	return func(s *Scanner) {
+		s.concurrency = n
+	}
+}
+
+// New creates and returns a new Scanner ready for use.
+func New(opts ...Option) *Scanner {
+	s := &Scanner{
+		kinds:          DefaultKinds,
+		appLabels:      applications.NewParser().Labels,
+		pipelineLabels: pipelines.NewParser().Labels,
+		progress:       io.Discard,
+		concurrency:    DefaultConcurrency,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Inventory discovers the Applications, Pipelines, Repositories and Argo CD
+// Applications.
+//
+// If some of the lists fail, the inventory is returned with a PartialError and
+// the lists are recorded in its Coverage.
+func (s *Scanner) Inventory(ctx context.Context) (*inventory.Inventory, error) {
+	var failures []Failure
+	apps, err := s.Applications(ctx)
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, err
+	}
+	pls, err := s.Pipelines(ctx)
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, err
+	}
+	repos, err := s.Repositories(ctx)
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, err
+	}
+	argoApps, argoAppSets, err := s.ArgoApplications(ctx)
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, err
+	}
+
+	return &inventory.Inventory{
+		Applications:        apps,
+		Pipelines:           pls,
+		Repositories:        repos,
+		ArgoApplications:    argoApps,
+		ArgoApplicationSets: argoAppSets,
+		Coverage:            NewCoverage(failures),
+	}, partialError(failures)
+}
+
+// Applications discovers the Applications from the labels on the configured
+// kinds of resource.
+//
+// Only the metadata of the resources is listed from sources that support it,
+// unless versions are detected.
+//
+// If some of the lists fail, the Applications are discovered from the lists
+// that succeeded and returned with a PartialError.
+func (s *Scanner) Applications(ctx context.Context) ([]applications.Application, error) {
+	gvks := make([]schema.GroupVersionKind, len(s.kinds))
+	for i, gk := range s.kinds {
+		gvks[i] = gk.WithVersion("")
+	}
+	requests, err := s.requests(gvks...)
+	if err != nil {
+		return nil, err
+	}
+	var failures []Failure
+	p, err := s.applicationsParser(ctx)
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, err
+	}
+
+	hasPartOf := client.HasLabels([]string{s.appLabels.PartOf})
+	results := make([][]metav1.PartialObjectMetadata, len(requests))
+	objects := make([][]unstructured.Unstructured, len(requests))
+	err = s.run(ctx, requests, func(ctx context.Context, i int) error {
+		if s.versions {
+			objs, err := requests[i].src.List(ctx, requests[i].gvk, requests[i].listOptions([]client.ListOption{hasPartOf})...)
+			objects[i] = objs
+			return err
+		}
+		objs, err := listMetadata(ctx, requests[i], hasPartOf)
+		results[i] = objs
+		return err
+	})
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, err
+	}
+
+	for _, gk := range s.kinds {
+		var found int
+		for i, req := range requests {
+			if req.gvk.GroupKind() != gk {
+				continue
+			}
+			found += len(results[i]) + len(objects[i])
+			p.Cluster = sourceName(req.src)
+			p.AddMetadata(results[i])
+			p.AddUnstructured(objects[i])
+		}
+		fmt.Fprintf(s.progress, "found %d %s\n", found, gk)
+	}
+
+	return p.Applications(), partialError(failures)
+}
+
+// ApplicationsFromObjects discovers the Applications from resources that were
+// read from another source e.g. rendered manifests.
+//
+// Only the configured kinds of resource in the configured namespaces are
+// parsed, resources without a namespace are always parsed.
+func (s *Scanner) ApplicationsFromObjects(objs []unstructured.Unstructured) ([]applications.Application, error) {
+	var scanned []unstructured.Unstructured
+	for _, obj := range objs {
+		if !s.scanKind(obj.GroupVersionKind().GroupKind()) {
+			continue
+		}
+		if ns := obj.GetNamespace(); ns != "" && !s.scanNamespace(ns) {
+			continue
+		}
+		if _, ok := obj.GetLabels()[s.appLabels.PartOf]; !ok {
+			continue
+		}
+		scanned = append(scanned, obj)
+	}
+	fmt.Fprintf(s.progress, "found %d resources\n", len(scanned))
+
+	p := applications.NewParser(s.parserOptions()...)
+	p.AddUnstructured(scanned)
+	return p.Applications(), nil
+}
+
+// Images discovers the images of the containers in the workloads of
+// Applications, in the configured kinds of resource.
+//
+// If some of the lists fail, the images are discovered from the lists that
+// succeeded and returned with a PartialError.
+func (s *Scanner) Images(ctx context.Context) ([]images.Usage, error) {
+	var failures []Failure
+	res := []images.Usage{}
+	for _, gk := range s.kinds {
+		objs, err := s.list(ctx, gk.WithVersion(""), client.HasLabels([]string{s.appLabels.PartOf}))
+		if err := collectFailures(err, &failures); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(s.progress, "found %d %s\n", len(objs), gk)
+		for i := range objs {
+			res = append(res, s.imageUsages(objs[i])...)
+		}
+	}
+	return res, partialError(failures)
+}
+
+// imageUsages returns the images of the containers of a workload of an
+// Application.
+func (s *Scanner) imageUsages(obj unstructured.Unstructured) []images.Usage {
+	labels := obj.GetLabels()
+	app := labels[s.appLabels.Name]
+	if app == "" {
+		return nil
+	}
+	workload := obj.GetKind() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+	var res []images.Usage
+	for _, c := range workloads.Containers(obj) {
+		res = append(res, images.Usage{
+			Application: app,
+			Component:   labels[s.appLabels.Component],
+			Instance:    labels[s.appLabels.Instance],
+			Workload:    workload,
+			Container:   c.Name,
+			Init:        c.Init,
+			Image:       c.Image,
+		})
+	}
+	return res
+}
+
+// Pipelines discovers the Pipelines from the labels on Kustomizations.
+func (s *Scanner) Pipelines(ctx context.Context) ([]pipelines.Pipeline, error) {
+	var failures []Failure
+	kustomizations, err := s.PipelineKustomizations(ctx)
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, err
+	}
+	discovered, err := s.PipelinesFromKustomizations(kustomizations)
+	if err != nil {
+		return nil, err
+	}
+	return discovered, partialError(failures)
+}
+
+// PipelinesFromKustomizations discovers the Pipelines from the labels on the
+// Kustomizations.
+func (s *Scanner) PipelinesFromKustomizations(kustomizations []kustomizev1.Kustomization) ([]pipelines.Pipeline, error) {
+	objs := make([]runtime.Object, len(kustomizations))
+	for i := range kustomizations {
+		objs[i] = &kustomizations[i]
+	}
+
+	p := pipelines.NewParser(pipelines.WithLabels(
+		s.pipelineLabels.Pipeline, s.pipelineLabels.Environment, s.pipelineLabels.After))
+	if err := p.Add(objs); err != nil {
+		return nil, fmt.Errorf("failed to discover pipelines: %w", err)
+	}
+	discovered, err := p.Pipelines()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover pipelines: %w", err)
+	}
+
+	return discovered, nil
+}
+
+// PipelineStages returns the stages of the Pipeline with the Kustomizations
+// that deliver each stage.
+func (s *Scanner) PipelineStages(pl pipelines.Pipeline, kustomizations []kustomizev1.Kustomization) []pipelines.Stage {
+	return pipelines.Stages(pl, s.pipelineLabels, kustomizations)
+}
+
+// Repositories discovers the repositories and the refs that GitRepositories
+// track.
+func (s *Scanner) Repositories(ctx context.Context) ([]flux.Repository, error) {
+	var failures []Failure
+	repositories, err := s.GitRepositories(ctx)
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(s.progress, "found %d git repositories\n", len(repositories))
+
+	p := flux.NewParser()
+	if err := p.Add(repositories); err != nil {
+		return nil, fmt.Errorf("failed to discover repositories: %w", err)
+	}
+
+	return p.Repositories(), partialError(failures)
+}
+
+// ArgoApplications returns the Argo CD Applications and ApplicationSets, or
+// nothing if Argo CD is not installed.
+func (s *Scanner) ArgoApplications(ctx context.Context) ([]argocd.Application, []argocd.ApplicationSet, error) {
+	p := argocd.NewParser()
+	var failures []Failure
+	for _, gvk := range []schema.GroupVersionKind{argocd.ApplicationKind, argocd.ApplicationSetKind} {
+		objs, err := s.list(ctx, gvk)
+		if err := collectFailures(err, &failures); err != nil {
+			return nil, nil, fmt.Errorf("failed to list %s: %w", gvk.GroupKind(), err)
+		}
+
+		if err := p.Add(objs); err != nil {
+			return nil, nil, fmt.Errorf("failed to discover argo cd applications: %w", err)
+		}
+	}
+	argoApps := p.Applications()
+	if len(argoApps) > 0 {
+		fmt.Fprintf(s.progress, "found %d argo cd applications\n", len(argoApps))
+	}
+
+	return argoApps, p.ApplicationSets(), partialError(withoutNoMatches(failures))
+}
+
+// PipelineKustomizations returns the Kustomizations that are labelled as part
+// of a pipeline.
+func (s *Scanner) PipelineKustomizations(ctx context.Context) ([]kustomizev1.Kustomization, error) {
+	kustomizations, err := s.Kustomizations(ctx, client.HasLabels([]string{s.pipelineLabels.Pipeline}))
+	var failures []Failure
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(s.progress, "found %d kustomizations\n", len(kustomizations))
+	return kustomizations, partialError(failures)
+}
+
+// Kustomizations returns the Kustomizations in the configured namespaces.
+func (s *Scanner) Kustomizations(ctx context.Context, opts ...client.ListOption) ([]kustomizev1.Kustomization, error) {
+	objs, err := s.list(ctx, kustomizationKind, opts...)
+	var failures []Failure
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, fmt.Errorf("failed to list kustomizations: %w", err)
+	}
+
+	res := make([]kustomizev1.Kustomization, len(objs))
+	for i := range objs {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objs[i].Object, &res[i]); err != nil {
+			return nil, fmt.Errorf("failed to convert kustomization %s: %w", client.ObjectKeyFromObject(&objs[i]), err)
+		}
+	}
+	return res, partialError(failures)
+}
+
+// GitRepositories returns the GitRepositories in the configured namespaces.
+func (s *Scanner) GitRepositories(ctx context.Context) ([]sourcev1.GitRepository, error) {
+	objs, err := s.list(ctx, gitRepositoryKind)
+	var failures []Failure
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, fmt.Errorf("failed to list git repositories: %w", err)
+	}
+
+	res := make([]sourcev1.GitRepository, len(objs))
+	for i := range objs {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objs[i].Object, &res[i]); err != nil {
+			return nil, fmt.Errorf("failed to convert git repository %s: %w", client.ObjectKeyFromObject(&objs[i]), err)
+		}
+	}
+	return res, partialError(failures)
+}
+
+// ListedKinds returns the kinds of resource that are listed when scanning the
+// inventory, the configured kinds and the Flux and Argo CD kinds.
+func (s *Scanner) ListedKinds() []schema.GroupKind {
+	kinds := append([]schema.GroupKind{}, s.kinds...)
+	for _, gvk := range []schema.GroupVersionKind{kustomizationKind, gitRepositoryKind, argocd.ApplicationKind, argocd.ApplicationSetKind} {
+		kinds = append(kinds, gvk.GroupKind())
+	}
+	return kinds
+}
+
+// applicationsParser returns a parser for the Applications, listing the
+// Kustomizations in pipelines if environments are identified from pipelines.
+//
+// If the list of Kustomizations fails, the parser is returned with a
+// PartialError.
+func (s *Scanner) applicationsParser(ctx context.Context) (*applications.Parser, error) {
+	if s.environments == nil || !s.environments.Pipelines || s.environments.Kustomizations != nil {
+		return applications.NewParser(s.parserOptions()...), nil
+	}
+
+	kustomizations, err := s.PipelineKustomizations(ctx)
+	var failures []Failure
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, err
+	}
+	envs := *s.environments
+	envs.Kustomizations = map[types.NamespacedName]string{}
+	for _, v := range kustomizations {
+		if env := v.GetLabels()[s.pipelineLabels.Environment]; env != "" {
+			envs.Kustomizations[client.ObjectKeyFromObject(&v)] = env
+		}
+	}
+	opts := append(s.parserOptions(), applications.WithEnvironments(envs))
+	return applications.NewParser(opts...), partialError(failures)
+}
+
+func (s *Scanner) parserOptions() []func(*applications.Parser) {
+	opts := []func(*applications.Parser){
+		applications.WithLabels(s.appLabels.Name, s.appLabels.PartOf, s.appLabels.Instance, s.appLabels.Component),
+	}
+	if s.environments != nil {
+		opts = append(opts, applications.WithEnvironments(*s.environments))
+	}
+	if s.versions {
+		opts = append(opts, applications.WithVersions())
+	}
+	return opts
+}
+
+// sourceName returns the name of the source, or an empty string if the source
+// is not named.
+func sourceName(src source.Source) string {
+	if n, ok := src.(source.Named); ok {
+		return n.Name()
+	}
+	return ""
+}
+
+func (s *Scanner) scanKind(gk schema.GroupKind) bool {
+	for _, v := range s.kinds {
+		if v == gk {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scanner) scanNamespace(ns string) bool {
+	if len(s.namespaces) == 0 {
+		return true
+	}
+	for _, v := range s.namespaces {
+		if v == ns {
+			return true
+		}
+	}
+	return false
+}
+
+// list lists the resources of the kind in each of the sources, in each of the
+// configured namespaces.
+//
+// If some of the lists fail, the resources from the lists that succeeded are
+// returned with a PartialError.
+func (s *Scanner) list(ctx context.Context, gvk schema.GroupVersionKind, opts ...client.ListOption) ([]unstructured.Unstructured, error) {
+	requests, err := s.requests(gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]unstructured.Unstructured, len(requests))
+	err = s.run(ctx, requests, func(ctx context.Context, i int) error {
+		objs, err := requests[i].src.List(ctx, gvk, requests[i].listOptions(opts)...)
+		results[i] = objs
+		return err
+	})
+	var failures []Failure
+	if err := collectFailures(err, &failures); err != nil {
+		return nil, err
+	}
+
+	var res []unstructured.Unstructured
+	for _, objs := range results {
+		res = append(res, objs...)
+	}
+	return res, partialError(failures)
+}
+
+// listRequest is a list of a kind of resource from a source, in a namespace.
+type listRequest struct {
+	src       source.Source
+	gvk       schema.GroupVersionKind
+	namespace string
+}
+
+func (r listRequest) listOptions(opts []client.ListOption) []client.ListOption {
+	// The options are copied as they are shared by concurrent requests.
+	return append(opts[:len(opts):len(opts)], client.InNamespace(r.namespace))
+}
+
+func (r listRequest) String() string {
+	if r.namespace == "" {
+		return r.gvk.GroupKind().String()
+	}
+	return r.gvk.GroupKind().String() + " in " + r.namespace
+}
+
+// requests returns the requests to list each of the kinds from each of the
+// sources, in each of the configured namespaces.
+func (s *Scanner) requests(gvks ...schema.GroupVersionKind) ([]listRequest, error) {
+	if len(s.sources) == 0 {
+		return nil, ErrNoSources
+	}
+	namespaces := s.namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var res []listRequest
+	for _, gvk := range gvks {
+		for _, src := range s.sources {
+			for _, ns := range namespaces {
+				res = append(res, listRequest{src: src, gvk: gvk, namespace: ns})
+			}
+		}
+	}
+	return res, nil
+}
+
+// run calls list with the index of each of the requests, with at most the
+// configured concurrency.
+//
+// Requests to sources that review access are skipped if they are not
+// permitted.
+//
+// The requests that fail are returned in a PartialError, unless the context
+// is cancelled, when the scan is abandoned and the context error is returned.
+//
+// When there is more than one request, the progress is reported as each
+// request completes.
+func (s *Scanner) run(ctx context.Context, requests []listRequest, list func(context.Context, int) error) error {
+	var g errgroup.Group
+	g.SetLimit(max(s.concurrency, 1))
+
+	var mu sync.Mutex
+	var completed int
+	errs := make([]error, len(requests))
+	for i := range requests {
+		i := i
+		g.Go(func() error {
+			errs[i] = checkAccess(ctx, requests[i])
+			if errs[i] == nil {
+				errs[i] = list(ctx, i)
+			}
+			if len(requests) > 1 {
+				mu.Lock()
+				defer mu.Unlock()
+				completed++
+				status := "listed"
+				if errors.Is(errs[i], ErrForbidden) {
+					status = "skipped"
+				} else if errs[i] != nil {
+					status = "failed to list"
+				}
+				fmt.Fprintf(s.progress, "%s %s (%d/%d)\n", status, requests[i], completed, len(requests))
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	if err := ctx.Err(); errors.Is(err, context.Canceled) {
+		return err
+	}
+	var failures []Failure
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, Failure{Kind: requests[i].gvk.GroupKind(), Namespace: requests[i].namespace, Err: err})
+		}
+	}
+	return partialError(failures)
+}
+
+// checkAccess returns ErrForbidden if the source reviews access and the
+// request is not permitted, or ErrForbiddenAllNamespaces if the request is for
+// all namespaces.
+//
+// If the review fails the request is made anyway, and it fails if it is not
+// permitted.
+func checkAccess(ctx context.Context, req listRequest) error {
+	ar, ok := req.src.(source.AccessReviewer)
+	if !ok {
+		return nil
+	}
+	allowed, err := ar.CanList(ctx, req.gvk, req.namespace)
+	if err != nil || allowed {
+		return nil
+	}
+	if req.namespace == "" {
+		return ErrForbiddenAllNamespaces
+	}
+	return ErrForbidden
+}
+
+// listMetadata lists the metadata of the resources, sources that can't list
+// only the metadata list the complete resources and the metadata is extracted.
+func listMetadata(ctx context.Context, req listRequest, opts ...client.ListOption) ([]metav1.PartialObjectMetadata, error) {
+	if ml, ok := req.src.(source.MetadataLister); ok {
+		return ml.ListMetadata(ctx, req.gvk, req.listOptions(opts)...)
+	}
+
+	objs, err := req.src.List(ctx, req.gvk, req.listOptions(opts)...)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]metav1.PartialObjectMetadata, len(objs))
+	for i := range objs {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objs[i].Object, &res[i]); err != nil {
+			return nil, fmt.Errorf("failed to convert %s metadata: %w", client.ObjectKeyFromObject(&objs[i]), err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,650 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/pkg/images"
+	"github.com/gitops-tools/apps-scanner/pkg/inventory"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+	"github.com/gitops-tools/apps-scanner/pkg/source"
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+func TestScanner_Applications(t *testing.T) {
+	cl := makeClient(t,
+		makeDeployment("cart", "dev", map[string]string{
+			"app.kubernetes.io/name":    "cart",
+			"app.kubernetes.io/part-of": "sockshop",
+		}),
+		makeDeployment("orders", "prod", map[string]string{
+			"app.kubernetes.io/name":    "orders",
+			"app.kubernetes.io/part-of": "sockshop",
+		}),
+		makeDeployment("unlabelled", "dev", nil),
+	)
+
+	scanTests := []struct {
+		name string
+		opts []Option
+		want []applications.Application
+	}{
+		{
+			name: "all namespaces",
+			opts: []Option{WithClient(cl)},
+			want: []applications.Application{
+				{Name: "cart", Instances: []string{""}, Components: []string{""}, Parents: []applications.Application{{Name: "sockshop"}}},
+				{Name: "orders", Instances: []string{""}, Components: []string{""}, Parents: []applications.Application{{Name: "sockshop"}}},
+				{Name: "sockshop"},
+			},
+		},
+		{
+			name: "restricted to a namespace",
+			opts: []Option{WithClient(cl), WithNamespaces("prod")},
+			want: []applications.Application{
+				{Name: "orders", Instances: []string{""}, Components: []string{""}, Parents: []applications.Application{{Name: "sockshop"}}},
+				{Name: "sockshop"},
+			},
+		},
+		{
+			name: "no configured kinds",
+			opts: []Option{WithClient(cl), WithKinds()},
+			want: []applications.Application{},
+		},
+	}
+
+	for _, tt := range scanTests {
+		t.Run(tt.name, func(t *testing.T) {
+			apps, err := New(tt.opts...).Applications(context.TODO())
+			test.AssertNoError(t, err)
+
+			if diff := cmp.Diff(tt.want, apps); diff != "" {
+				t.Fatalf("failed to scan applications:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScanner_Applications_with_custom_labels(t *testing.T) {
+	cl := makeClient(t,
+		makeDeployment("cart", "dev", map[string]string{
+			"example.com/name":      "cart",
+			"example.com/app":       "sockshop",
+			"example.com/instance":  "cart-dev",
+			"example.com/component": "backend",
+		}),
+	)
+	s := New(WithClient(cl), WithApplicationLabels(applications.Labels{
+		Name:      "example.com/name",
+		PartOf:    "example.com/app",
+		Instance:  "example.com/instance",
+		Component: "example.com/component",
+	}))
+
+	apps, err := s.Applications(context.TODO())
+	test.AssertNoError(t, err)
+
+	want := []applications.Application{
+		{Name: "cart", Instances: []string{"cart-dev"}, Components: []string{"backend"}, Parents: []applications.Application{{Name: "sockshop"}}},
+		{Name: "sockshop"},
+	}
+	if diff := cmp.Diff(want, apps); diff != "" {
+		t.Fatalf("failed to scan applications:\n%s", diff)
+	}
+}
+
+func TestScanner_Applications_with_multiple_clients(t *testing.T) {
+	dev := makeClient(t, makeDeployment("cart", "sockshop", map[string]string{
+		"app.kubernetes.io/name":     "cart",
+		"app.kubernetes.io/part-of":  "sockshop",
+		"app.kubernetes.io/instance": "cart-dev",
+	}))
+	prod := makeClient(t, makeDeployment("cart", "sockshop", map[string]string{
+		"app.kubernetes.io/name":     "cart",
+		"app.kubernetes.io/part-of":  "sockshop",
+		"app.kubernetes.io/instance": "cart-prod",
+	}))
+
+	apps, err := New(WithClient(dev), WithClient(prod)).Applications(context.TODO())
+	test.AssertNoError(t, err)
+
+	want := []string{"cart-dev", "cart-prod"}
+	if diff := cmp.Diff(want, apps[0].Instances); diff != "" {
+		t.Fatalf("failed to scan applications:\n%s", diff)
+	}
+}
+
+func TestScanner_Applications_reports_progress(t *testing.T) {
+	cl := makeClient(t, makeDeployment("cart", "dev", map[string]string{
+		"app.kubernetes.io/name":    "cart",
+		"app.kubernetes.io/part-of": "sockshop",
+	}))
+	var progress bytes.Buffer
+	s := New(WithClient(cl), WithNamespaces("dev", "prod"), WithConcurrency(1), WithProgress(&progress))
+
+	_, err := s.Applications(context.TODO())
+	test.AssertNoError(t, err)
+
+	want := `listed Deployment.apps in dev (1/2)
+listed Deployment.apps in prod (2/2)
+found 1 Deployment.apps
+`
+	if diff := cmp.Diff(want, progress.String()); diff != "" {
+		t.Fatalf("failed to report progress:\n%s", diff)
+	}
+}
+
+func TestScanner_Applications_environments(t *testing.T) {
+	objs := []unstructured.Unstructured{
+		makeUnstructured(t, makeDeployment("cart", "dev", map[string]string{
+			"app.kubernetes.io/name":                "cart",
+			"app.kubernetes.io/part-of":             "sockshop",
+			"app.kubernetes.io/instance":            "cart-dev",
+			"kustomize.toolkit.fluxcd.io/name":      "sockshop-dev",
+			"kustomize.toolkit.fluxcd.io/namespace": "flux-system",
+		})),
+		makeUnstructured(t, makeDeployment("cart", "prod", map[string]string{
+			"app.kubernetes.io/name":     "cart",
+			"app.kubernetes.io/part-of":  "sockshop",
+			"app.kubernetes.io/instance": "cart-prod",
+		})),
+		makeUnstructured(t, makeKustomization("sockshop-dev", "flux-system", map[string]string{
+			"gitops.pro/pipeline":             "sockshop",
+			"gitops.pro/pipeline-environment": "staging",
+		})),
+	}
+	src := &namedSource{Source: source.NewObjects(objs...), name: "prod-cluster"}
+
+	apps, err := New(WithSource(src), WithEnvironments(applications.Environments{Pipelines: true, Cluster: true})).Applications(context.TODO())
+	test.AssertNoError(t, err)
+
+	want := []applications.Environment{
+		{Name: "prod-cluster", Instances: []string{"cart-prod"}, Components: []string{""}},
+		{Name: "staging", Instances: []string{"cart-dev"}, Components: []string{""}},
+	}
+	if diff := cmp.Diff(want, apps[0].Environments); diff != "" {
+		t.Fatalf("failed to identify environments:\n%s", diff)
+	}
+}
+
+func TestScanner_Applications_versions(t *testing.T) {
+	deploy := makeDeployment("cart", "prod", map[string]string{
+		"app.kubernetes.io/name":      "cart",
+		"app.kubernetes.io/part-of":   "sockshop",
+		"app.kubernetes.io/instance":  "cart-prod",
+		"app.kubernetes.io/component": "backend",
+	})
+	deploy.Spec.Template.Spec.Containers = []corev1.Container{{Name: "cart", Image: "ghcr.io/sockshop/cart:v1.2.0"}}
+	cl := makeClient(t, deploy)
+
+	apps, err := New(WithClient(cl), WithVersions()).Applications(context.TODO())
+	test.AssertNoError(t, err)
+
+	want := []applications.Version{
+		{Component: "backend", Instance: "cart-prod", Version: "v1.2.0", Source: applications.VersionFromImage},
+	}
+	if diff := cmp.Diff(want, apps[0].Versions); diff != "" {
+		t.Fatalf("failed to detect versions:\n%s", diff)
+	}
+}
+
+func TestScanner_Images(t *testing.T) {
+	deploy := makeDeployment("cart", "prod", map[string]string{
+		"app.kubernetes.io/name":      "cart",
+		"app.kubernetes.io/part-of":   "sockshop",
+		"app.kubernetes.io/instance":  "cart-prod",
+		"app.kubernetes.io/component": "backend",
+	})
+	deploy.Spec.Template.Spec.Containers = []corev1.Container{{Name: "cart", Image: "ghcr.io/sockshop/cart:v1.2.0"}}
+	deploy.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: "migrate", Image: "ghcr.io/sockshop/cart-migrate:v1.2.0"}}
+	unlabelled := makeDeployment("unlabelled", "prod", nil)
+	unlabelled.Spec.Template.Spec.Containers = []corev1.Container{{Name: "nginx", Image: "nginx"}}
+	cl := makeClient(t, deploy, unlabelled)
+
+	usages, err := New(WithClient(cl)).Images(context.TODO())
+	test.AssertNoError(t, err)
+
+	want := []images.Usage{
+		{Application: "cart", Component: "backend", Instance: "cart-prod", Workload: "Deployment/prod/cart", Container: "cart", Image: "ghcr.io/sockshop/cart:v1.2.0"},
+		{Application: "cart", Component: "backend", Instance: "cart-prod", Workload: "Deployment/prod/cart", Container: "migrate", Init: true, Image: "ghcr.io/sockshop/cart-migrate:v1.2.0"},
+	}
+	if diff := cmp.Diff(want, usages); diff != "" {
+		t.Fatalf("failed to scan images:\n%s", diff)
+	}
+}
+
+func TestScanner_Applications_partial(t *testing.T) {
+	src := &failingSource{
+		Source: source.NewObjects(
+			makeUnstructured(t, makeDeployment("cart", "dev", map[string]string{
+				"app.kubernetes.io/name":    "cart",
+				"app.kubernetes.io/part-of": "sockshop",
+			})),
+			makeUnstructured(t, makeDeployment("orders", "prod", map[string]string{
+				"app.kubernetes.io/name":    "orders",
+				"app.kubernetes.io/part-of": "sockshop",
+			})),
+		),
+		namespace: "prod",
+		err:       errors.New("connection refused"),
+	}
+
+	apps, err := New(WithSource(src), WithNamespaces("dev", "prod")).Applications(context.TODO())
+
+	var partial *PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("got error %v, want a partial error", err)
+	}
+	wantFailures := []Failure{
+		{Kind: schema.GroupKind{Group: "apps", Kind: "Deployment"}, Namespace: "prod", Err: src.err},
+	}
+	if diff := cmp.Diff(wantFailures, partial.Failures, cmpopts.EquateErrors()); diff != "" {
+		t.Fatalf("failed to report failures:\n%s", diff)
+	}
+	test.AssertErrorMatch(t, "scan is incomplete: failed to list Deployment.apps in prod: connection refused", err)
+
+	want := []applications.Application{
+		{Name: "cart", Instances: []string{""}, Components: []string{""}, Parents: []applications.Application{{Name: "sockshop"}}},
+		{Name: "sockshop"},
+	}
+	if diff := cmp.Diff(want, apps); diff != "" {
+		t.Fatalf("failed to scan applications:\n%s", diff)
+	}
+}
+
+func TestScanner_Applications_forbidden(t *testing.T) {
+	src := &reviewingSource{
+		Source: source.NewObjects(
+			makeUnstructured(t, makeDeployment("cart", "dev", map[string]string{
+				"app.kubernetes.io/name":    "cart",
+				"app.kubernetes.io/part-of": "sockshop",
+			})),
+			makeUnstructured(t, makeDeployment("orders", "prod", map[string]string{
+				"app.kubernetes.io/name":    "orders",
+				"app.kubernetes.io/part-of": "sockshop",
+			})),
+		),
+		forbidden: "prod",
+	}
+	var progress bytes.Buffer
+
+	apps, err := New(WithSource(src), WithNamespaces("dev", "prod"), WithProgress(&progress), WithConcurrency(1)).Applications(context.TODO())
+
+	var partial *PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("got error %v, want a partial error", err)
+	}
+	wantFailures := []Failure{
+		{Kind: schema.GroupKind{Group: "apps", Kind: "Deployment"}, Namespace: "prod", Err: ErrForbidden},
+	}
+	if diff := cmp.Diff(wantFailures, partial.Failures, cmpopts.EquateErrors()); diff != "" {
+		t.Fatalf("failed to report failures:\n%s", diff)
+	}
+	test.AssertErrorMatch(t, "scan is incomplete: skipped Deployment.apps in prod: access denied", err)
+	if diff := cmp.Diff([]string{"dev"}, src.listed); diff != "" {
+		t.Fatalf("failed to skip forbidden lists:\n%s", diff)
+	}
+
+	wantProgress := "listed Deployment.apps in dev (1/2)\nskipped Deployment.apps in prod (2/2)\nfound 1 Deployment.apps\n"
+	if diff := cmp.Diff(wantProgress, progress.String()); diff != "" {
+		t.Fatalf("failed to report progress:\n%s", diff)
+	}
+	want := []applications.Application{
+		{Name: "cart", Instances: []string{""}, Components: []string{""}, Parents: []applications.Application{{Name: "sockshop"}}},
+		{Name: "sockshop"},
+	}
+	if diff := cmp.Diff(want, apps); diff != "" {
+		t.Fatalf("failed to scan applications:\n%s", diff)
+	}
+}
+
+func TestScanner_Applications_forbidden_all_namespaces(t *testing.T) {
+	src := &reviewingSource{
+		Source: source.NewObjects(
+			makeUnstructured(t, makeDeployment("cart", "dev", map[string]string{
+				"app.kubernetes.io/name": "cart",
+			})),
+		),
+		forbidden: "",
+	}
+
+	apps, err := New(WithSource(src)).Applications(context.TODO())
+
+	var partial *PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("got error %v, want a partial error", err)
+	}
+	wantFailures := []Failure{
+		{Kind: schema.GroupKind{Group: "apps", Kind: "Deployment"}, Err: ErrForbiddenAllNamespaces},
+	}
+	if diff := cmp.Diff(wantFailures, partial.Failures, cmpopts.EquateErrors()); diff != "" {
+		t.Fatalf("failed to report failures:\n%s", diff)
+	}
+	if !partial.Failures[0].Forbidden() {
+		t.Fatal("failure in all namespaces is not forbidden")
+	}
+	test.AssertErrorMatch(t, "skipped Deployment.apps: access denied in all namespaces, configure the namespaces to scan", err)
+	if len(src.listed) != 0 {
+		t.Fatalf("listed forbidden namespaces %v", src.listed)
+	}
+	if len(apps) != 0 {
+		t.Fatalf("got applications %v, want none", apps)
+	}
+}
+
+func TestNewCoverage(t *testing.T) {
+	deployments := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	coverage := NewCoverage([]Failure{
+		{Kind: deployments, Namespace: "prod", Err: ErrForbidden},
+		{Kind: deployments, Namespace: "staging", Err: apierrors.NewForbidden(gr, "", errors.New("denied"))},
+		{Kind: deployments, Err: context.DeadlineExceeded},
+	})
+
+	want := &inventory.Coverage{
+		Skipped: []inventory.Gap{
+			{Kind: "Deployment.apps", Namespace: "prod", Reason: "access denied"},
+			{Kind: "Deployment.apps", Namespace: "staging", Reason: `deployments.apps is forbidden: denied`},
+		},
+		Failed: []inventory.Gap{
+			{Kind: "Deployment.apps", Reason: "context deadline exceeded"},
+		},
+	}
+	if diff := cmp.Diff(want, coverage); diff != "" {
+		t.Fatalf("failed to record coverage:\n%s", diff)
+	}
+	if coverage := NewCoverage(nil); coverage != nil {
+		t.Fatalf("got coverage %v for a complete scan", coverage)
+	}
+}
+
+func TestScanner_Inventory_partial(t *testing.T) {
+	src := &failingSource{
+		Source:    source.NewObjects(),
+		namespace: "prod",
+		err:       errors.New("connection refused"),
+	}
+
+	inv, err := New(WithSource(src), WithNamespaces("dev", "prod")).Inventory(context.TODO())
+
+	var partial *PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("got error %v, want a partial error", err)
+	}
+	var failed []string
+	for _, v := range partial.Failures {
+		failed = append(failed, v.Kind.String())
+	}
+	want := []string{
+		"Deployment.apps",
+		"Kustomization.kustomize.toolkit.fluxcd.io",
+		"GitRepository.source.toolkit.fluxcd.io",
+		"Application.argoproj.io",
+		"ApplicationSet.argoproj.io",
+	}
+	if diff := cmp.Diff(want, failed); diff != "" {
+		t.Fatalf("failed to report failures:\n%s", diff)
+	}
+	if inv == nil {
+		t.Fatal("no inventory was returned with the partial error")
+	}
+	if inv.Coverage == nil || len(inv.Coverage.Failed) != len(want) {
+		t.Fatalf("got coverage %v, want %d failed lists", inv.Coverage, len(want))
+	}
+}
+
+func TestScanner_Applications_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	src := &failingSource{Source: source.NewObjects(), err: context.Canceled}
+
+	_, err := New(WithSource(src)).Applications(ctx)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want %v", err, context.Canceled)
+	}
+	var partial *PartialError
+	if errors.As(err, &partial) {
+		t.Fatal("cancelled scan returned a partial error")
+	}
+}
+
+func TestScanner_without_sources(t *testing.T) {
+	_, err := New().Applications(context.TODO())
+
+	if !errors.Is(err, ErrNoSources) {
+		t.Fatalf("got error %v, want %v", err, ErrNoSources)
+	}
+}
+
+func TestScanner_ApplicationsFromObjects(t *testing.T) {
+	objs := []unstructured.Unstructured{
+		makeUnstructured(t, makeDeployment("cart", "dev", map[string]string{
+			"app.kubernetes.io/name":    "cart",
+			"app.kubernetes.io/part-of": "sockshop",
+		})),
+		makeUnstructured(t, makeDeployment("orders", "prod", map[string]string{
+			"app.kubernetes.io/name":    "orders",
+			"app.kubernetes.io/part-of": "sockshop",
+		})),
+	}
+
+	apps, err := New(WithNamespaces("dev")).ApplicationsFromObjects(objs)
+	test.AssertNoError(t, err)
+
+	want := []applications.Application{
+		{Name: "cart", Instances: []string{""}, Components: []string{""}, Parents: []applications.Application{{Name: "sockshop"}}},
+		{Name: "sockshop"},
+	}
+	if diff := cmp.Diff(want, apps); diff != "" {
+		t.Fatalf("failed to scan applications:\n%s", diff)
+	}
+}
+
+func TestScanner_Inventory(t *testing.T) {
+	cl := makeClient(t,
+		makeDeployment("cart", "dev", map[string]string{
+			"app.kubernetes.io/name":    "cart",
+			"app.kubernetes.io/part-of": "sockshop",
+		}),
+		makeKustomization("sockshop-dev", "dev", map[string]string{
+			pipelines.PipelineNameLabel:        "sockshop",
+			pipelines.PipelineEnvironmentLabel: "dev",
+		}),
+		makeKustomization("sockshop-prod", "prod", map[string]string{
+			pipelines.PipelineNameLabel:             "sockshop",
+			pipelines.PipelineEnvironmentLabel:      "prod",
+			pipelines.PipelineEnvironmentAfterLabel: "dev",
+		}),
+		&sourcev1.GitRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: "sockshop", Namespace: "flux-system"},
+			Spec: sourcev1.GitRepositorySpec{
+				URL:       "https://github.com/example/sockshop.git",
+				Reference: &sourcev1.GitRepositoryRef{Branch: "main"},
+			},
+		},
+	)
+
+	inv, err := New(WithClient(cl)).Inventory(context.TODO())
+	test.AssertNoError(t, err)
+
+	if diff := cmp.Diff([]pipelines.Pipeline{{Name: "sockshop", Environments: []string{"dev", "prod"}, After: map[string]string{"prod": "dev"}}}, inv.Pipelines); diff != "" {
+		t.Fatalf("failed to scan pipelines:\n%s", diff)
+	}
+	wantRepos := []flux.Repository{
+		{
+			URL: "https://github.com/example/sockshop.git",
+			Refs: []flux.RepositoryRef{
+				{NamespacedName: types.NamespacedName{Name: "sockshop", Namespace: "flux-system"}, Ref: sourcev1.GitRepositoryRef{Branch: "main"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(wantRepos, inv.Repositories); diff != "" {
+		t.Fatalf("failed to scan repositories:\n%s", diff)
+	}
+	if l := len(inv.Applications); l != 2 {
+		t.Fatalf("got %d applications, want 2", l)
+	}
+}
+
+func TestScanner_Inventory_from_source(t *testing.T) {
+	src := source.NewObjects(
+		makeUnstructured(t, makeDeployment("cart", "dev", map[string]string{
+			"app.kubernetes.io/name":    "cart",
+			"app.kubernetes.io/part-of": "sockshop",
+		})),
+		unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "kustomize.toolkit.fluxcd.io/v1",
+			"kind":       "Kustomization",
+			"metadata": map[string]interface{}{
+				"name":      "sockshop-dev",
+				"namespace": "dev",
+				"labels": map[string]interface{}{
+					pipelines.PipelineNameLabel:        "sockshop",
+					pipelines.PipelineEnvironmentLabel: "dev",
+				},
+			},
+		}},
+	)
+
+	inv, err := New(WithSource(src)).Inventory(context.TODO())
+	test.AssertNoError(t, err)
+
+	want := &inventory.Inventory{
+		Applications: []applications.Application{
+			{Name: "cart", Instances: []string{""}, Components: []string{""}, Parents: []applications.Application{{Name: "sockshop"}}},
+			{Name: "sockshop"},
+		},
+		Pipelines:    []pipelines.Pipeline{{Name: "sockshop", Environments: []string{"dev"}}},
+		Repositories: []flux.Repository{},
+	}
+	if diff := cmp.Diff(want, inv, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("failed to scan inventory:\n%s", diff)
+	}
+}
+
+func TestScanner_ListedKinds(t *testing.T) {
+	s := New(WithKinds(schema.GroupKind{Group: "apps", Kind: "StatefulSet"}))
+
+	want := []schema.GroupKind{
+		{Group: "apps", Kind: "StatefulSet"},
+		{Group: "kustomize.toolkit.fluxcd.io", Kind: "Kustomization"},
+		{Group: "source.toolkit.fluxcd.io", Kind: "GitRepository"},
+		{Group: "argoproj.io", Kind: "Application"},
+		{Group: "argoproj.io", Kind: "ApplicationSet"},
+	}
+	if diff := cmp.Diff(want, s.ListedKinds()); diff != "" {
+		t.Fatalf("failed to list kinds:\n%s", diff)
+	}
+}
+
+// failingSource fails to list in the namespace, or in every namespace if the
+// namespace is empty.
+type failingSource struct {
+	source.Source
+	namespace string
+	err       error
+}
+
+func (s *failingSource) List(ctx context.Context, gvk schema.GroupVersionKind, opts ...client.ListOption) ([]unstructured.Unstructured, error) {
+	listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+	if s.namespace == "" || listOpts.Namespace == s.namespace {
+		return nil, s.err
+	}
+	return s.Source.List(ctx, gvk, opts...)
+}
+
+// namedSource is a source with a name.
+type namedSource struct {
+	source.Source
+	name string
+}
+
+func (s *namedSource) Name() string {
+	return s.name
+}
+
+// reviewingSource denies access to list in the forbidden namespace, and
+// records the namespaces that are listed.
+type reviewingSource struct {
+	source.Source
+	forbidden string
+	listed    []string
+}
+
+func (s *reviewingSource) CanList(ctx context.Context, gvk schema.GroupVersionKind, namespace string) (bool, error) {
+	return namespace != s.forbidden, nil
+}
+
+func (s *reviewingSource) List(ctx context.Context, gvk schema.GroupVersionKind, opts ...client.ListOption) ([]unstructured.Unstructured, error) {
+	s.listed = append(s.listed, (&client.ListOptions{}).ApplyOptions(opts).Namespace)
+	return s.Source.List(ctx, gvk, opts...)
+}
+
+func makeClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	test.AssertNoError(t, clientgoscheme.AddToScheme(scheme))
+	test.AssertNoError(t, kustomizev1.AddToScheme(scheme))
+	test.AssertNoError(t, sourcev1.AddToScheme(scheme))
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{appsv1.SchemeGroupVersion})
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(mapper).
+		WithObjects(objs...).
+		Build()
+}
+
+func makeDeployment(name, namespace string, labels map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+}
+
+func makeKustomization(name, namespace string, labels map[string]string) *kustomizev1.Kustomization {
+	return &kustomizev1.Kustomization{
+		TypeMeta: metav1.TypeMeta{APIVersion: kustomizev1.GroupVersion.String(), Kind: kustomizev1.KustomizationKind},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+}
+
+func makeUnstructured(t *testing.T, obj runtime.Object) unstructured.Unstructured {
+	t.Helper()
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	test.AssertNoError(t, err)
+	return unstructured.Unstructured{Object: m}
+}
@@ -0,0 +1,253 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrWatchNotSupported is returned when watching with a client that can't
+// watch.
+var ErrWatchNotSupported = errors.New("client does not support watching")
+
+// DefaultPageSize is the number of objects that are requested in each page
+// when listing from a cluster.
+const DefaultPageSize = 500
+
+// DefaultBackoff is the backoff between retries of requests that fail with
+// transient errors, the request is made at most four times.
+var DefaultBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    4,
+}
+
+// Cluster is a Source that lists objects from a live cluster.
+type Cluster struct {
+	client   client.Client
+	name     string
+	pageSize int64
+	backoff  wait.Backoff
+}
+
+// ClusterOption configures a Cluster.
+type ClusterOption func(*Cluster)
+
+// WithPageSize configures the number of objects that are requested in each
+// page when listing, zero lists all the objects in a single request.
+func WithPageSize(n int64) ClusterOption {
+	return func(c *Cluster) {
+		c.pageSize = n
+	}
+}
+
+// WithName configures the name of the cluster, for example the name of the
+// kubeconfig context.
+func WithName(name string) ClusterOption {
+	return func(c *Cluster) {
+		c.name = name
+	}
+}
+
+// WithBackoff configures the backoff between retries of requests that fail
+// with transient errors, the Steps are the maximum number of requests.
+func WithBackoff(b wait.Backoff) ClusterOption {
+	return func(c *Cluster) {
+		c.backoff = b
+	}
+}
+
+// NewCluster creates and returns a new Cluster that lists with the client.
+//
+// The client must implement client.WithWatch for the Cluster to be watched.
+func NewCluster(cl client.Client, opts ...ClusterOption) *Cluster {
+	c := &Cluster{client: cl, pageSize: DefaultPageSize, backoff: DefaultBackoff}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Name implements the Named interface.
+func (c *Cluster) Name() string {
+	return c.name
+}
+
+// List implements the Source interface.
+//
+// Kinds without a version are listed at the preferred version in the
+// cluster.
+func (c *Cluster) List(ctx context.Context, gvk schema.GroupVersionKind, opts ...client.ListOption) ([]unstructured.Unstructured, error) {
+	gvk, err := c.resolve(gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []unstructured.Unstructured
+	err = c.paginate(ctx, func() client.ObjectList {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		return list
+	}, opts, func(list client.ObjectList) {
+		items := list.(*unstructured.UnstructuredList).Items
+		for i := range items {
+			// Items in a list are not guaranteed to have a kind.
+			items[i].SetGroupVersionKind(gvk)
+		}
+		res = append(res, items...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ListMetadata implements the MetadataLister interface.
+func (c *Cluster) ListMetadata(ctx context.Context, gvk schema.GroupVersionKind, opts ...client.ListOption) ([]metav1.PartialObjectMetadata, error) {
+	gvk, err := c.resolve(gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []metav1.PartialObjectMetadata
+	err = c.paginate(ctx, func() client.ObjectList {
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		return list
+	}, opts, func(list client.ObjectList) {
+		items := list.(*metav1.PartialObjectMetadataList).Items
+		for i := range items {
+			items[i].SetGroupVersionKind(gvk)
+		}
+		res = append(res, items...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Watch implements the Watcher interface.
+func (c *Cluster) Watch(ctx context.Context, gvk schema.GroupVersionKind, opts ...client.ListOption) (watch.Interface, error) {
+	cl, ok := c.client.(client.WithWatch)
+	if !ok {
+		return nil, ErrWatchNotSupported
+	}
+	gvk, err := c.resolve(gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	return cl.Watch(ctx, list, opts...)
+}
+
+// CanList implements the AccessReviewer interface with a
+// SelfSubjectAccessReview.
+func (c *Cluster) CanList(ctx context.Context, gvk schema.GroupVersionKind, namespace string) (bool, error) {
+	mapping, err := c.client.RESTMapper().RESTMapping(gvk.GroupKind())
+	if err != nil {
+		return false, fmt.Errorf("failed to find kind %s: %w", gvk.GroupKind(), err)
+	}
+
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "list",
+				Group:     mapping.Resource.Group,
+				Resource:  mapping.Resource.Resource,
+			},
+		},
+	}
+	if err := c.client.Create(ctx, review); err != nil {
+		return false, fmt.Errorf("failed to review access to %s: %w", gvk.GroupKind(), err)
+	}
+	return review.Status.Allowed, nil
+}
+
+// paginate lists the objects a page at a time, calling page with each page
+// that is listed.
+//
+// newList is called for every page and returns an empty list of the objects
+// to list.
+func (c *Cluster) paginate(ctx context.Context, newList func() client.ObjectList, opts []client.ListOption, page func(client.ObjectList)) error {
+	// The options are copied so that concurrent lists don't share them.
+	opts = append(opts[:len(opts):len(opts)], client.Limit(c.pageSize))
+	var token string
+	for {
+		list := newList()
+		if err := c.listWithRetries(ctx, list, append(opts, client.Continue(token))...); err != nil {
+			return err
+		}
+		page(list)
+
+		token = list.GetContinue()
+		if token == "" || c.pageSize == 0 {
+			return nil
+		}
+	}
+}
+
+// listWithRetries lists the objects, retrying with the configured backoff if
+// the request fails with a transient error.
+func (c *Cluster) listWithRetries(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	backoff := c.backoff
+	for {
+		err := c.client.List(ctx, list, opts...)
+		if err == nil || !isTransient(err) || backoff.Steps <= 1 {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff.Step()):
+		}
+	}
+}
+
+// isTransient returns true if the error is likely to be resolved by retrying
+// the request.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) || apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) || apierrors.IsUnexpectedServerError(err) {
+		return true
+	}
+	if utilnet.IsConnectionReset(err) || utilnet.IsConnectionRefused(err) || utilnet.IsProbableEOF(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (c *Cluster) resolve(gvk schema.GroupVersionKind) (schema.GroupVersionKind, error) {
+	if gvk.Version != "" {
+		return gvk, nil
+	}
+	mapping, err := c.client.RESTMapper().RESTMapping(gvk.GroupKind())
+	if err != nil {
+		return gvk, fmt.Errorf("failed to find kind %s: %w", gvk.GroupKind(), err)
+	}
+	return mapping.GroupVersionKind, nil
+}
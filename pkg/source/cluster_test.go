@@ -0,0 +1,368 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+func TestCluster_List(t *testing.T) {
+	src := NewCluster(makeClient(t,
+		makeDeployment("cart", "dev", map[string]string{"app.kubernetes.io/part-of": "sockshop"}),
+		makeDeployment("orders", "dev", nil),
+		makeDeployment("cart", "prod", map[string]string{"app.kubernetes.io/part-of": "sockshop"}),
+	))
+
+	listTests := []struct {
+		name string
+		gvk  schema.GroupVersionKind
+		opts []client.ListOption
+		want []string
+	}{
+		{
+			name: "all objects",
+			gvk:  deploymentKind,
+			want: []string{"dev/cart", "dev/orders", "prod/cart"},
+		},
+		{
+			name: "kind without a version",
+			gvk:  schema.GroupVersionKind{Group: "apps", Kind: "Deployment"},
+			want: []string{"dev/cart", "dev/orders", "prod/cart"},
+		},
+		{
+			name: "with options",
+			gvk:  deploymentKind,
+			opts: []client.ListOption{client.InNamespace("dev"), client.HasLabels{"app.kubernetes.io/part-of"}},
+			want: []string{"dev/cart"},
+		},
+	}
+
+	for _, tt := range listTests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := src.List(context.TODO(), tt.gvk, tt.opts...)
+			test.AssertNoError(t, err)
+
+			if diff := cmp.Diff(tt.want, objectNames(objs)); diff != "" {
+				t.Fatalf("failed to list objects:\n%s", diff)
+			}
+			for _, obj := range objs {
+				if gvk := obj.GroupVersionKind(); gvk != deploymentKind {
+					t.Fatalf("got kind %s, want %s", gvk, deploymentKind)
+				}
+			}
+		})
+	}
+}
+
+func TestCluster_List_paginates(t *testing.T) {
+	cl := &pagingClient{Client: makeClient(t,
+		makeDeployment("cart", "dev", nil),
+		makeDeployment("orders", "dev", nil),
+		makeDeployment("payment", "dev", nil),
+	)}
+
+	pageTests := []struct {
+		pageSize     int64
+		wantRequests int
+	}{
+		{pageSize: 0, wantRequests: 1},
+		{pageSize: 1, wantRequests: 3},
+		{pageSize: 2, wantRequests: 2},
+		{pageSize: DefaultPageSize, wantRequests: 1},
+	}
+
+	for _, tt := range pageTests {
+		t.Run(fmt.Sprintf("page size %d", tt.pageSize), func(t *testing.T) {
+			cl.requests = 0
+			src := NewCluster(cl, WithPageSize(tt.pageSize))
+
+			objs, err := src.List(context.TODO(), deploymentKind, client.InNamespace("dev"))
+			test.AssertNoError(t, err)
+
+			if diff := cmp.Diff([]string{"dev/cart", "dev/orders", "dev/payment"}, objectNames(objs)); diff != "" {
+				t.Fatalf("failed to list objects:\n%s", diff)
+			}
+			if cl.requests != tt.wantRequests {
+				t.Fatalf("got %d requests, want %d", cl.requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestCluster_ListMetadata(t *testing.T) {
+	cl := &pagingClient{Client: makeClient(t,
+		makeDeployment("cart", "dev", map[string]string{"app.kubernetes.io/part-of": "sockshop"}),
+		makeDeployment("orders", "dev", nil),
+		makeDeployment("cart", "prod", map[string]string{"app.kubernetes.io/part-of": "sockshop"}),
+	)}
+	src := NewCluster(cl, WithPageSize(1))
+
+	objs, err := src.ListMetadata(context.TODO(), schema.GroupVersionKind{Group: "apps", Kind: "Deployment"},
+		client.HasLabels{"app.kubernetes.io/part-of"})
+	test.AssertNoError(t, err)
+
+	want := []metav1.PartialObjectMetadata{
+		{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "cart", Namespace: "dev", Labels: map[string]string{"app.kubernetes.io/part-of": "sockshop"}},
+		},
+		{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "cart", Namespace: "prod", Labels: map[string]string{"app.kubernetes.io/part-of": "sockshop"}},
+		},
+	}
+	if diff := cmp.Diff(want, objs, cmpopts.IgnoreFields(metav1.ObjectMeta{}, "ResourceVersion")); diff != "" {
+		t.Fatalf("failed to list metadata:\n%s", diff)
+	}
+	if cl.requests != 2 {
+		t.Fatalf("got %d requests, want 2", cl.requests)
+	}
+}
+
+func TestCluster_List_retries(t *testing.T) {
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	retryTests := []struct {
+		name         string
+		errs         []error
+		steps        int
+		wantRequests int
+		wantErr      string
+	}{
+		{
+			name:         "transient error",
+			errs:         []error{apierrors.NewServiceUnavailable("unavailable"), apierrors.NewTooManyRequests("slow down", 1)},
+			steps:        4,
+			wantRequests: 3,
+		},
+		{
+			name:         "too many transient errors",
+			errs:         []error{apierrors.NewInternalError(errors.New("1")), apierrors.NewInternalError(errors.New("2")), apierrors.NewInternalError(errors.New("3"))},
+			steps:        2,
+			wantRequests: 2,
+			wantErr:      "Internal error occurred: 2",
+		},
+		{
+			name:         "error that is not transient",
+			errs:         []error{apierrors.NewForbidden(gr, "", errors.New("denied"))},
+			steps:        4,
+			wantRequests: 1,
+			wantErr:      "forbidden",
+		},
+		{
+			name:         "cancelled",
+			errs:         []error{context.Canceled},
+			steps:        4,
+			wantRequests: 1,
+			wantErr:      "context canceled",
+		},
+	}
+
+	for _, tt := range retryTests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := &erroringClient{Client: makeClient(t, makeDeployment("cart", "dev", nil)), errs: tt.errs}
+			src := NewCluster(cl, WithBackoff(wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: tt.steps}))
+
+			objs, err := src.List(context.TODO(), deploymentKind)
+			if tt.wantErr != "" {
+				test.AssertErrorMatch(t, tt.wantErr, err)
+			} else {
+				test.AssertNoError(t, err)
+				if diff := cmp.Diff([]string{"dev/cart"}, objectNames(objs)); diff != "" {
+					t.Fatalf("failed to list objects:\n%s", diff)
+				}
+			}
+			if cl.requests != tt.wantRequests {
+				t.Fatalf("got %d requests, want %d", cl.requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestCluster_List_unknown_kind(t *testing.T) {
+	src := NewCluster(makeClient(t))
+
+	_, err := src.List(context.TODO(), schema.GroupVersionKind{Group: "argoproj.io", Kind: "Application"})
+
+	if !meta.IsNoMatchError(err) {
+		t.Fatalf("got error %v, want no match error", err)
+	}
+}
+
+func TestCluster_CanList(t *testing.T) {
+	var reviewed []authorizationv1.ResourceAttributes
+	cl := interceptor.NewClient(makeClient(t), interceptor.Funcs{
+		Create: func(ctx context.Context, cl client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			review := obj.(*authorizationv1.SelfSubjectAccessReview)
+			reviewed = append(reviewed, *review.Spec.ResourceAttributes)
+			review.Status.Allowed = review.Spec.ResourceAttributes.Namespace == "dev"
+			return nil
+		},
+	})
+	src := NewCluster(cl)
+
+	accessTests := []struct {
+		namespace string
+		want      bool
+	}{
+		{namespace: "dev", want: true},
+		{namespace: "prod", want: false},
+		{namespace: "", want: false},
+	}
+
+	for _, tt := range accessTests {
+		t.Run(fmt.Sprintf("namespace %q", tt.namespace), func(t *testing.T) {
+			reviewed = nil
+
+			allowed, err := src.CanList(context.TODO(), schema.GroupVersionKind{Group: "apps", Kind: "Deployment"}, tt.namespace)
+			test.AssertNoError(t, err)
+
+			if allowed != tt.want {
+				t.Fatalf("got allowed %v, want %v", allowed, tt.want)
+			}
+			want := []authorizationv1.ResourceAttributes{
+				{Namespace: tt.namespace, Verb: "list", Group: "apps", Resource: "deployments"},
+			}
+			if diff := cmp.Diff(want, reviewed); diff != "" {
+				t.Fatalf("failed to review access:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCluster_CanList_unknown_kind(t *testing.T) {
+	src := NewCluster(makeClient(t))
+
+	_, err := src.CanList(context.TODO(), schema.GroupVersionKind{Group: "argoproj.io", Kind: "Application"}, "")
+
+	if !meta.IsNoMatchError(err) {
+		t.Fatalf("got error %v, want no match error", err)
+	}
+}
+
+func TestCluster_Watch(t *testing.T) {
+	cl := makeClient(t)
+	src := NewCluster(cl)
+
+	w, err := src.Watch(context.TODO(), schema.GroupVersionKind{Group: "apps", Kind: "Deployment"}, client.InNamespace("dev"))
+	test.AssertNoError(t, err)
+	defer w.Stop()
+
+	test.AssertNoError(t, cl.Create(context.TODO(), makeDeployment("cart", "dev", nil)))
+
+	event := <-w.ResultChan()
+	if event.Type != watch.Added {
+		t.Fatalf("got event %s, want %s", event.Type, watch.Added)
+	}
+	if name := event.Object.(client.Object).GetName(); name != "cart" {
+		t.Fatalf("got object %q, want %q", name, "cart")
+	}
+}
+
+func TestCluster_Watch_not_supported(t *testing.T) {
+	src := NewCluster(struct{ client.Client }{makeClient(t)})
+
+	_, err := src.Watch(context.TODO(), deploymentKind)
+
+	if err != ErrWatchNotSupported {
+		t.Fatalf("got error %v, want %v", err, ErrWatchNotSupported)
+	}
+}
+
+// pagingClient returns the objects from the wrapped client a page at a time
+// because the fake client ignores the limit, the continue token is the index
+// of the next object.
+type pagingClient struct {
+	client.Client
+	requests int
+}
+
+func (c *pagingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.requests++
+	listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+	err := c.Client.List(ctx, list, &client.ListOptions{Namespace: listOpts.Namespace, LabelSelector: listOpts.LabelSelector})
+	if err != nil {
+		return err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	start := 0
+	if listOpts.Continue != "" {
+		start, err = strconv.Atoi(listOpts.Continue)
+		if err != nil {
+			return err
+		}
+	}
+	end := len(items)
+	if listOpts.Limit > 0 && start+int(listOpts.Limit) < end {
+		end = start + int(listOpts.Limit)
+		list.SetContinue(strconv.Itoa(end))
+	}
+	return meta.SetList(list, items[start:end])
+}
+
+// erroringClient fails each list with the next of the errors, once there are
+// no more errors, the wrapped client is used.
+type erroringClient struct {
+	client.Client
+	errs     []error
+	requests int
+}
+
+func (c *erroringClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.requests++
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func makeClient(t *testing.T, objs ...client.Object) client.WithWatch {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	test.AssertNoError(t, clientgoscheme.AddToScheme(scheme))
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{appsv1.SchemeGroupVersion})
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(mapper).
+		WithObjects(objs...).
+		Build()
+}
+
+func makeDeployment(name, namespace string, labels map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package source
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gitops-tools/apps-scanner/pkg/manifests"
+)
+
+// Objects is a Source that lists a fixed set of objects, for example read
+// from manifest files or provided as test fixtures.
+type Objects struct {
+	objs []unstructured.Unstructured
+}
+
+// NewObjects creates and returns a new Objects that lists the objects.
+func NewObjects(objs ...unstructured.Unstructured) *Objects {
+	return &Objects{objs: objs}
+}
+
+// NewManifests creates and returns a new Objects that lists the objects in
+// the manifest files and directories.
+//
+// This includes the output of `kubectl get -o yaml`, `kubectl cluster-info
+// dump` directories and gzipped tar archives of manifests.
+func NewManifests(paths ...string) (*Objects, error) {
+	var objs []unstructured.Unstructured
+	for _, path := range paths {
+		read, err := manifests.ReadPath(path)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, read...)
+	}
+
+	return NewObjects(objs...), nil
+}
+
+// List implements the Source interface.
+//
+// Only the namespace and label selector options are supported, and the
+// version of the kind is ignored so that objects are listed regardless of the
+// version they were written at.
+func (o *Objects) List(ctx context.Context, gvk schema.GroupVersionKind, opts ...client.ListOption) ([]unstructured.Unstructured, error) {
+	listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+
+	var res []unstructured.Unstructured
+	for _, obj := range o.objs {
+		if obj.GroupVersionKind().GroupKind() != gvk.GroupKind() {
+			continue
+		}
+		if listOpts.Namespace != "" && obj.GetNamespace() != listOpts.Namespace {
+			continue
+		}
+		if listOpts.LabelSelector != nil && !listOpts.LabelSelector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		res = append(res, *obj.DeepCopy())
+	}
+
+	return res, nil
+}
@@ -0,0 +1,119 @@
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+var deploymentKind = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+func TestObjects_List(t *testing.T) {
+	src := NewObjects(
+		makeObject(deploymentKind, "cart", "dev", map[string]string{"app.kubernetes.io/part-of": "sockshop"}),
+		makeObject(deploymentKind, "cart", "prod", map[string]string{"app.kubernetes.io/part-of": "sockshop"}),
+		makeObject(deploymentKind, "orders", "dev", nil),
+		makeObject(schema.GroupVersionKind{Group: "apps", Version: "v1beta1", Kind: "Deployment"}, "payment", "dev", nil),
+		makeObject(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, "cart", "dev", nil),
+	)
+
+	listTests := []struct {
+		name string
+		gvk  schema.GroupVersionKind
+		opts []client.ListOption
+		want []string
+	}{
+		{
+			name: "all versions of the kind",
+			gvk:  deploymentKind,
+			want: []string{"dev/cart", "prod/cart", "dev/orders", "dev/payment"},
+		},
+		{
+			name: "kind without a version",
+			gvk:  schema.GroupVersionKind{Group: "apps", Kind: "Deployment"},
+			want: []string{"dev/cart", "prod/cart", "dev/orders", "dev/payment"},
+		},
+		{
+			name: "in a namespace",
+			gvk:  deploymentKind,
+			opts: []client.ListOption{client.InNamespace("prod")},
+			want: []string{"prod/cart"},
+		},
+		{
+			name: "with labels",
+			gvk:  deploymentKind,
+			opts: []client.ListOption{client.HasLabels{"app.kubernetes.io/part-of"}},
+			want: []string{"dev/cart", "prod/cart"},
+		},
+		{
+			name: "unknown kind",
+			gvk:  schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Application"},
+		},
+	}
+
+	for _, tt := range listTests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := src.List(context.TODO(), tt.gvk, tt.opts...)
+			test.AssertNoError(t, err)
+
+			if diff := cmp.Diff(tt.want, objectNames(objs)); diff != "" {
+				t.Fatalf("failed to list objects:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestObjects_List_returns_copies(t *testing.T) {
+	src := NewObjects(makeObject(deploymentKind, "cart", "dev", nil))
+
+	objs, err := src.List(context.TODO(), deploymentKind)
+	test.AssertNoError(t, err)
+	objs[0].SetName("modified")
+
+	objs, err = src.List(context.TODO(), deploymentKind)
+	test.AssertNoError(t, err)
+	if diff := cmp.Diff([]string{"dev/cart"}, objectNames(objs)); diff != "" {
+		t.Fatalf("listed objects were modified:\n%s", diff)
+	}
+}
+
+func TestNewManifests(t *testing.T) {
+	src, err := NewManifests("testdata/sockshop.yaml")
+	test.AssertNoError(t, err)
+
+	objs, err := src.List(context.TODO(), deploymentKind, client.HasLabels{"app.kubernetes.io/part-of"})
+	test.AssertNoError(t, err)
+
+	if diff := cmp.Diff([]string{"sockshop-dev/cart"}, objectNames(objs)); diff != "" {
+		t.Fatalf("failed to list objects:\n%s", diff)
+	}
+}
+
+func TestNewManifests_missing(t *testing.T) {
+	_, err := NewManifests("testdata/missing.yaml")
+
+	test.AssertErrorMatch(t, "failed to read manifests", err)
+}
+
+func makeObject(gvk schema.GroupVersionKind, name, namespace string, labels map[string]string) unstructured.Unstructured {
+	u := unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	u.SetName(name)
+	u.SetNamespace(namespace)
+	u.SetLabels(labels)
+	return u
+}
+
+func objectNames(objs []unstructured.Unstructured) []string {
+	var res []string
+	for _, obj := range objs {
+		res = append(res, obj.GetNamespace()+"/"+obj.GetName())
+	}
+	return res
+}
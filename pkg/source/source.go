@@ -0,0 +1,51 @@
+// Package source provides the objects that are scanned, from live clusters,
+// manifest files or in-memory fixtures.
+package source
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Source lists the objects that are scanned.
+type Source interface {
+	// List returns the objects of the kind that match the options.
+	//
+	// If the version of the kind is empty, any version of the kind is
+	// listed.
+	List(ctx context.Context, gvk schema.GroupVersionKind, opts ...client.ListOption) ([]unstructured.Unstructured, error)
+}
+
+// MetadataLister is implemented by Sources that can list only the metadata of
+// objects, which is much cheaper than listing the complete objects.
+type MetadataLister interface {
+	// ListMetadata returns the metadata of the objects of the kind that match
+	// the options.
+	ListMetadata(ctx context.Context, gvk schema.GroupVersionKind, opts ...client.ListOption) ([]metav1.PartialObjectMetadata, error)
+}
+
+// Watcher is implemented by Sources that can watch for changes to objects.
+type Watcher interface {
+	// Watch returns a watch for changes to the objects of the kind that match
+	// the options.
+	Watch(ctx context.Context, gvk schema.GroupVersionKind, opts ...client.ListOption) (watch.Interface, error)
+}
+
+// Named is implemented by Sources that have a name, for example the name of
+// the cluster that is listed.
+type Named interface {
+	Name() string
+}
+
+// AccessReviewer is implemented by Sources that can check whether objects can
+// be listed before listing them.
+type AccessReviewer interface {
+	// CanList returns true if the objects of the kind can be listed in the
+	// namespace, or in all namespaces if the namespace is empty.
+	CanList(ctx context.Context, gvk schema.GroupVersionKind, namespace string) (bool, error)
+}
@@ -2,24 +2,257 @@ package visualise
 
 import (
 	"github.com/emicklei/dot"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/flux"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
 )
 
+// DOTOption is a functional option for configuring the detail in the graphs
+// generated by NewDOT.
+type DOTOption func(*dotOptions)
+
+type dotOptions struct {
+	clusters       bool
+	components     bool
+	kustomizations map[types.NamespacedName]kustomizev1.Kustomization
+	repositories   map[types.NamespacedName]sourcev1.GitRepository
+}
+
+// WithClusters draws Applications that have child Applications as subgraph
+// clusters containing their children.
+func WithClusters() DOTOption {
+	return func(o *dotOptions) {
+		o.clusters = true
+	}
+}
+
+// WithComponents draws the components and instances of each Application as
+// nodes linked to the Application.
+func WithComponents() DOTOption {
+	return func(o *dotOptions) {
+		o.components = true
+	}
+}
+
+// WithFluxObjects draws the Kustomizations that deliver each Application and
+// the GitRepositories that they are sourced from.
+//
+// The Kustomizations and GitRepositories are coloured by the health reported
+// in their status.
+func WithFluxObjects(kustomizations []kustomizev1.Kustomization, repositories []sourcev1.GitRepository) DOTOption {
+	return func(o *dotOptions) {
+		o.kustomizations = map[types.NamespacedName]kustomizev1.Kustomization{}
+		for _, v := range kustomizations {
+			o.kustomizations[types.NamespacedName{Name: v.GetName(), Namespace: v.GetNamespace()}] = v
+		}
+		o.repositories = map[types.NamespacedName]sourcev1.GitRepository{}
+		for _, v := range repositories {
+			o.repositories[types.NamespacedName{Name: v.GetName(), Namespace: v.GetNamespace()}] = v
+		}
+	}
+}
+
 // NewDOT converts a set of Applications to a graph of dependencies.
-func NewDOT(apps []applications.Application) *dot.Graph {
+func NewDOT(apps []applications.Application, opts ...DOTOption) *dot.Graph {
+	o := dotOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	g := dot.NewGraph(dot.Directed)
+	b := newGraphBuilder(g, apps, o.clusters)
 	for _, app := range apps {
-		g.Node(app.Name)
+		b.appNode(app.Name)
 	}
 
 	for _, app := range apps {
 		for _, p := range app.Parents {
-			parentNode := g.Node(p.Name)
-			appNode := g.Node(app.Name)
+			parentNode := b.appNode(p.Name)
+			appNode := b.appNode(app.Name)
 			g.Edge(appNode, parentNode)
 		}
 	}
 
+	if o.components {
+		for _, app := range apps {
+			addComponents(g, b, app)
+		}
+	}
+
+	if o.kustomizations != nil {
+		for _, app := range apps {
+			addFluxObjects(g, b, app, o)
+		}
+	}
+
+	return g
+}
+
+// NewPipelinesDOT converts a set of Pipelines to a graph with a cluster per
+// Pipeline, linking each environment to the next.
+func NewPipelinesDOT(pls []pipelines.Pipeline) *dot.Graph {
+	g := dot.NewGraph(dot.Directed)
+	for _, pipeline := range pls {
+		sub := g.Subgraph(pipeline.Name, dot.ClusterOption{})
+		var previous *dot.Node
+		for _, env := range pipeline.Environments {
+			n := sub.Node(pipeline.Name + "/" + env).Label(env)
+			if previous != nil {
+				sub.Edge(*previous, n)
+			}
+			previous = &n
+		}
+	}
+
+	return g
+}
+
+func addComponents(g *dot.Graph, b *graphBuilder, app applications.Application) {
+	appNode := b.appNode(app.Name)
+	container := b.containerFor(app.Name)
+	for _, c := range app.Components {
+		if c == "" {
+			continue
+		}
+		n := container.Node("component:"+app.Name+"/"+c).Label(c).Attr("shape", "component")
+		g.Edge(n, appNode)
+	}
+	for _, i := range app.Instances {
+		if i == "" {
+			continue
+		}
+		n := container.Node("instance:"+app.Name+"/"+i).Label(i).Attr("shape", "box").Attr("style", "rounded,dashed")
+		g.Edge(n, appNode).Dashed()
+	}
+}
+
+func addFluxObjects(g *dot.Graph, b *graphBuilder, app applications.Application, o dotOptions) {
+	appNode := b.appNode(app.Name)
+	for _, nn := range app.Kustomizations {
+		kn, created := b.node("kustomization:"+nn.String(), g)
+		if created {
+			kn.Label(nn.String()).Attr("shape", "folder")
+			k, ok := o.kustomizations[nn]
+			if ok {
+				colourByHealth(kn, flux.HealthFromConditions(k.Status.Conditions))
+				addSource(g, b, kn, k, o)
+			}
+		}
+		g.Edge(kn, appNode, "delivers")
+	}
+}
+
+func addSource(g *dot.Graph, b *graphBuilder, kn dot.Node, k kustomizev1.Kustomization, o dotOptions) {
+	if k.Spec.SourceRef.Kind != sourcev1.GitRepositoryKind {
+		return
+	}
+	nn := types.NamespacedName{Name: k.Spec.SourceRef.Name, Namespace: k.Spec.SourceRef.Namespace}
+	if nn.Namespace == "" {
+		nn.Namespace = k.GetNamespace()
+	}
+	rn, created := b.node("gitrepository:"+nn.String(), g)
+	if created {
+		rn.Label(nn.String()).Attr("shape", "cylinder")
+		if repo, ok := o.repositories[nn]; ok {
+			colourByHealth(rn, flux.HealthFromConditions(repo.Status.Conditions))
+		}
+	}
+	g.Edge(rn, kn)
+}
+
+var healthColours = map[flux.Health]string{
+	flux.HealthHealthy:     "palegreen",
+	flux.HealthUnhealthy:   "lightcoral",
+	flux.HealthProgressing: "lightyellow",
+}
+
+func colourByHealth(n dot.Node, h flux.Health) {
+	if colour, ok := healthColours[h]; ok {
+		n.Attr("style", "filled").Attr("fillcolor", colour)
+	}
+}
+
+// graphBuilder tracks the nodes that have been created, and the subgraph that
+// each Application is drawn within.
+//
+// Nodes in subgraphs can't be found from the root graph, so this keeps a
+// record of all nodes by their id.
+type graphBuilder struct {
+	root       *dot.Graph
+	clusters   bool
+	parents    map[string][]string
+	hasChild   map[string]bool
+	nodes      map[string]dot.Node
+	containers map[string]*dot.Graph
+}
+
+func newGraphBuilder(g *dot.Graph, apps []applications.Application, clusters bool) *graphBuilder {
+	b := &graphBuilder{
+		root:       g,
+		clusters:   clusters,
+		parents:    map[string][]string{},
+		hasChild:   map[string]bool{},
+		nodes:      map[string]dot.Node{},
+		containers: map[string]*dot.Graph{},
+	}
+	for _, app := range apps {
+		for _, p := range app.Parents {
+			b.parents[app.Name] = append(b.parents[app.Name], p.Name)
+			b.hasChild[p.Name] = true
+		}
+	}
+	return b
+}
+
+// node returns the node with the id, creating it in the graph if it doesn't
+// exist.
+func (b *graphBuilder) node(id string, g *dot.Graph) (dot.Node, bool) {
+	if n, ok := b.nodes[id]; ok {
+		return n, false
+	}
+	n := g.Node(id)
+	b.nodes[id] = n
+	return n, true
+}
+
+func (b *graphBuilder) appNode(name string) dot.Node {
+	n, _ := b.node(name, b.containerFor(name))
+	return n
+}
+
+// containerFor returns the graph that the named Application's node is drawn
+// in.
+//
+// When clustering, Applications with children are drawn in their own cluster,
+// and children are drawn within the cluster of their first parent.
+func (b *graphBuilder) containerFor(name string) *dot.Graph {
+	if !b.clusters {
+		return b.root
+	}
+	return b.container(name, map[string]bool{})
+}
+
+func (b *graphBuilder) container(name string, seen map[string]bool) *dot.Graph {
+	if g, ok := b.containers[name]; ok {
+		return g
+	}
+	// Guard against cycles in the parent relationships.
+	if seen[name] {
+		return b.root
+	}
+	seen[name] = true
+
+	g := b.root
+	if parents := b.parents[name]; len(parents) > 0 {
+		g = b.container(parents[0], seen)
+	}
+	if b.hasChild[name] {
+		g = g.Subgraph(name, dot.ClusterOption{})
+	}
+	b.containers[name] = g
 	return g
 }
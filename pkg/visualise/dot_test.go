@@ -3,10 +3,14 @@ package visualise
 import (
 	"testing"
 
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
 )
 
 func TestNewDOT(t *testing.T) {
@@ -62,3 +66,114 @@ func makeApplication(opts ...func(*applications.Application)) applications.Appli
 	}
 	return a
 }
+
+func TestNewPipelinesDOT(t *testing.T) {
+	g := NewPipelinesDOT([]pipelines.Pipeline{
+		{Name: "billing-pipeline", Environments: []string{"staging", "production"}},
+		{Name: "web-pipeline", Environments: []string{"staging"}},
+	})
+
+	want := `digraph  {
+	subgraph cluster_s1 {
+		label="billing-pipeline";
+		n3[label="production"];
+		n2[label="staging"];
+		n2->n3;
+		
+	}
+	subgraph cluster_s4 {
+		label="web-pipeline";
+		n5[label="staging"];
+		
+	}
+	
+	
+}
+`
+	if diff := cmp.Diff(want, g.String()); diff != "" {
+		t.Fatalf("failed to visualise pipelines: %s\n", diff)
+	}
+}
+
+func TestNewDOT_with_clusters(t *testing.T) {
+	g := NewDOT([]applications.Application{
+		makeApplication(),
+		applications.Application{Name: "billing-system"},
+		makeApplication(func(a *applications.Application) {
+			a.Name = "backend"
+			a.Parents = []applications.Application{{Name: "frontend"}}
+		})}, WithClusters())
+
+	want := `digraph  {
+	subgraph cluster_s1 {
+		subgraph cluster_s2 {
+			label="frontend";
+			n5[label="backend"];
+			n3[label="frontend"];
+			
+		}
+		label="billing-system";
+		n4[label="billing-system"];
+		
+	}
+	
+	n5->n3;
+	n3->n4;
+	
+}
+`
+	if diff := cmp.Diff(want, g.String()); diff != "" {
+		t.Fatalf("failed to visualise applications: %s\n", diff)
+	}
+}
+
+func TestNewDOT_with_components(t *testing.T) {
+	g := NewDOT([]applications.Application{makeApplication(), applications.Application{Name: "billing-system"}}, WithComponents())
+
+	want := `digraph  {
+	
+	n2[label="billing-system"];
+	n3[label="database",shape="component"];
+	n4[label="web",shape="component"];
+	n1[label="frontend"];
+	n6[label="production",shape="box",style="rounded,dashed"];
+	n5[label="staging",shape="box",style="rounded,dashed"];
+	n3->n1;
+	n4->n1;
+	n1->n2;
+	n6->n1[style="dashed"];
+	n5->n1[style="dashed"];
+	
+}
+`
+	if diff := cmp.Diff(want, g.String()); diff != "" {
+		t.Fatalf("failed to visualise applications: %s\n", diff)
+	}
+}
+
+func TestNewDOT_with_flux_objects(t *testing.T) {
+	k := kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Name: "repo-main", Namespace: "flux-system"}}
+	k.Spec.SourceRef = kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: "repo"}
+	k.Status.Conditions = []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue}}
+	r := sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Name: "repo", Namespace: "flux-system"}}
+	r.Status.Conditions = []metav1.Condition{{Type: "Ready", Status: metav1.ConditionFalse}}
+
+	g := NewDOT([]applications.Application{makeApplication(), applications.Application{Name: "billing-system"}},
+		WithFluxObjects([]kustomizev1.Kustomization{k}, []sourcev1.GitRepository{r}))
+
+	want := `digraph  {
+	
+	n2[label="billing-system"];
+	n1[label="frontend"];
+	n4[fillcolor="lightcoral",label="flux-system/repo",shape="cylinder",style="filled"];
+	n3[fillcolor="palegreen",label="flux-system/repo-main",shape="folder",style="filled"];
+	n1->n2;
+	n4->n3;
+	n3->n1[label="delivers"];
+	
+}
+`
+	if diff := cmp.Diff(want, g.String()); diff != "" {
+		t.Fatalf("failed to visualise applications: %s\n", diff)
+	}
+}
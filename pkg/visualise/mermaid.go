@@ -0,0 +1,43 @@
+package visualise
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emicklei/dot"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+// NewMermaid converts a set of Applications to a Mermaid flowchart of
+// dependencies.
+func NewMermaid(apps []applications.Application) string {
+	return dot.MermaidFlowchart(NewDOT(apps), dot.MermaidLeftToRight)
+}
+
+// NewPipelinesMermaid converts a set of Pipelines to a Mermaid flowchart with a
+// subgraph per Pipeline.
+//
+// The dot package doesn't render subgraphs as Mermaid, so this is generated
+// directly.
+func NewPipelinesMermaid(pls []pipelines.Pipeline) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR;\n")
+	for i, pipeline := range pls {
+		fmt.Fprintf(&b, "\tsubgraph p%d[%s]\n", i+1, mermaidEscape(pipeline.Name))
+		for j, env := range pipeline.Environments {
+			fmt.Fprintf(&b, "\t\tp%ds%d(%s);\n", i+1, j+1, mermaidEscape(env))
+		}
+		for j := 1; j < len(pipeline.Environments); j++ {
+			fmt.Fprintf(&b, "\t\tp%ds%d-->p%ds%d;\n", i+1, j, i+1, j+1)
+		}
+		b.WriteString("\tend\n")
+	}
+
+	return b.String()
+}
+
+func mermaidEscape(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
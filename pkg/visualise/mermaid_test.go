@@ -0,0 +1,44 @@
+package visualise
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+func TestNewMermaid(t *testing.T) {
+	m := NewMermaid([]applications.Application{makeApplication(), applications.Application{Name: "billing-system"}})
+
+	want := `flowchart LR;
+	n2("billing-system");
+	n1("frontend");
+	n1-->n2;
+`
+	if diff := cmp.Diff(want, m); diff != "" {
+		t.Fatalf("failed to visualise applications: %s\n", diff)
+	}
+}
+
+func TestNewPipelinesMermaid(t *testing.T) {
+	m := NewPipelinesMermaid([]pipelines.Pipeline{
+		{Name: "billing-pipeline", Environments: []string{"staging", "production"}},
+		{Name: "web-pipeline", Environments: []string{"staging"}},
+	})
+
+	want := `flowchart LR;
+	subgraph p1["billing-pipeline"]
+		p1s1("staging");
+		p1s2("production");
+		p1s1-->p1s2;
+	end
+	subgraph p2["web-pipeline"]
+		p2s1("staging");
+	end
+`
+	if diff := cmp.Diff(want, m); diff != "" {
+		t.Fatalf("failed to visualise pipelines: %s\n", diff)
+	}
+}
@@ -0,0 +1,71 @@
+package visualise
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+// NewPlantUML converts a set of Applications to a PlantUML component diagram
+// of dependencies.
+func NewPlantUML(apps []applications.Application) string {
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	ids := map[string]string{}
+	node := func(name string) string {
+		if id, ok := ids[name]; ok {
+			return id
+		}
+		id := fmt.Sprintf("n%d", len(ids)+1)
+		ids[name] = id
+		fmt.Fprintf(&b, "component %s as %s\n", plantUMLQuote(name), id)
+		return id
+	}
+
+	for _, app := range apps {
+		node(app.Name)
+	}
+
+	// The parent nodes are created first so that the declarations come before
+	// the edges that use them.
+	edges := []string{}
+	for _, app := range apps {
+		for _, p := range app.Parents {
+			edges = append(edges, fmt.Sprintf("%s --> %s\n", node(app.Name), node(p.Name)))
+		}
+	}
+	for _, e := range edges {
+		b.WriteString(e)
+	}
+	b.WriteString("@enduml\n")
+
+	return b.String()
+}
+
+// NewPipelinesPlantUML converts a set of Pipelines to a PlantUML component
+// diagram with a package per Pipeline.
+func NewPipelinesPlantUML(pls []pipelines.Pipeline) string {
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	for i, pipeline := range pls {
+		fmt.Fprintf(&b, "package %s {\n", plantUMLQuote(pipeline.Name))
+		for j, env := range pipeline.Environments {
+			fmt.Fprintf(&b, "  component %s as p%ds%d\n", plantUMLQuote(env), i+1, j+1)
+		}
+		for j := 1; j < len(pipeline.Environments); j++ {
+			fmt.Fprintf(&b, "  p%ds%d --> p%ds%d\n", i+1, j, i+1, j+1)
+		}
+		b.WriteString("}\n")
+	}
+	b.WriteString("@enduml\n")
+
+	return b.String()
+}
+
+// plantUMLQuote quotes a name, PlantUML has no escape for a double quote in a
+// quoted name, so double quotes are replaced with single quotes.
+func plantUMLQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
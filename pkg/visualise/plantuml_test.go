@@ -0,0 +1,66 @@
+package visualise
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+func TestNewPlantUML(t *testing.T) {
+	u := NewPlantUML([]applications.Application{
+		makeApplication(func(a *applications.Application) {
+			a.Name = "backend"
+			a.Parents = []applications.Application{{Name: "frontend"}}
+		}),
+		makeApplication(),
+	})
+
+	want := `@startuml
+component "backend" as n1
+component "frontend" as n2
+component "billing-system" as n3
+n1 --> n2
+n2 --> n3
+@enduml
+`
+	if diff := cmp.Diff(want, u); diff != "" {
+		t.Fatalf("failed to visualise applications: %s\n", diff)
+	}
+}
+
+func TestNewPlantUML_quoting(t *testing.T) {
+	u := NewPlantUML([]applications.Application{
+		{Name: "café", Parents: []applications.Application{{Name: `say "hello"`}}},
+	})
+
+	want := `@startuml
+component "café" as n1
+component "say 'hello'" as n2
+n1 --> n2
+@enduml
+`
+	if diff := cmp.Diff(want, u); diff != "" {
+		t.Fatalf("failed to visualise applications: %s\n", diff)
+	}
+}
+
+func TestNewPipelinesPlantUML(t *testing.T) {
+	u := NewPipelinesPlantUML([]pipelines.Pipeline{
+		{Name: "billing-pipeline", Environments: []string{"staging", "production"}},
+	})
+
+	want := `@startuml
+package "billing-pipeline" {
+  component "staging" as p1s1
+  component "production" as p1s2
+  p1s1 --> p1s2
+}
+@enduml
+`
+	if diff := cmp.Diff(want, u); diff != "" {
+		t.Fatalf("failed to visualise pipelines: %s\n", diff)
+	}
+}
@@ -0,0 +1,56 @@
+package visualise
+
+import (
+	"fmt"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+)
+
+const (
+	// DOTFormat renders diagrams as Graphviz DOT.
+	DOTFormat = "dot"
+
+	// MermaidFormat renders diagrams as Mermaid flowcharts.
+	MermaidFormat = "mermaid"
+
+	// PlantUMLFormat renders diagrams as PlantUML component diagrams.
+	PlantUMLFormat = "plantuml"
+)
+
+// Formats is the set of supported diagram formats.
+var Formats = []string{DOTFormat, MermaidFormat, PlantUMLFormat}
+
+// RenderApplications renders the Applications in the requested diagram format.
+//
+// The options are only used when rendering DOT diagrams.
+func RenderApplications(format string, apps []applications.Application, opts ...DOTOption) (string, error) {
+	switch format {
+	case DOTFormat:
+		return NewDOT(apps, opts...).String(), nil
+	case MermaidFormat:
+		return NewMermaid(apps), nil
+	case PlantUMLFormat:
+		return NewPlantUML(apps), nil
+	}
+
+	return "", unknownFormatError(format)
+}
+
+// RenderPipelines renders the Pipelines in the requested diagram format.
+func RenderPipelines(format string, pls []pipelines.Pipeline) (string, error) {
+	switch format {
+	case DOTFormat:
+		return NewPipelinesDOT(pls).String(), nil
+	case MermaidFormat:
+		return NewPipelinesMermaid(pls), nil
+	case PlantUMLFormat:
+		return NewPipelinesPlantUML(pls), nil
+	}
+
+	return "", unknownFormatError(format)
+}
+
+func unknownFormatError(format string) error {
+	return fmt.Errorf("unknown diagram format %q, must be one of %v", format, Formats)
+}
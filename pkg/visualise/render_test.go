@@ -0,0 +1,47 @@
+package visualise
+
+import (
+	"testing"
+
+	"github.com/gitops-tools/apps-scanner/pkg/applications"
+	"github.com/gitops-tools/apps-scanner/pkg/pipelines"
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+func TestRenderApplications(t *testing.T) {
+	apps := []applications.Application{makeApplication(), {Name: "billing-system"}}
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			s, err := RenderApplications(format, apps)
+			test.AssertNoError(t, err)
+			if s == "" {
+				t.Fatal("expected a rendered diagram")
+			}
+		})
+	}
+}
+
+func TestRenderApplications_unknown_format(t *testing.T) {
+	_, err := RenderApplications("svg", nil)
+
+	test.AssertErrorMatch(t, `unknown diagram format "svg"`, err)
+}
+
+func TestRenderPipelines(t *testing.T) {
+	pls := []pipelines.Pipeline{{Name: "billing-pipeline", Environments: []string{"staging"}}}
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			s, err := RenderPipelines(format, pls)
+			test.AssertNoError(t, err)
+			if s == "" {
+				t.Fatal("expected a rendered diagram")
+			}
+		})
+	}
+}
+
+func TestRenderPipelines_unknown_format(t *testing.T) {
+	_, err := RenderPipelines("svg", nil)
+
+	test.AssertErrorMatch(t, `unknown diagram format "svg"`, err)
+}
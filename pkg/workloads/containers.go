@@ -0,0 +1,77 @@
+// Package workloads extracts the containers from the pod templates of
+// workload resources.
+package workloads
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Container is a container in the pods of a workload.
+type Container struct {
+	Name  string
+	Image string
+	// Init is true for init containers.
+	Init bool
+}
+
+// Containers returns the containers of the pods of a workload, the containers
+// are followed by the init containers.
+//
+// The pods of a CronJob are templated in the template of its jobs, Pods have
+// their own spec, and the pods of every other kind are templated in
+// spec.template, which includes Deployments, StatefulSets, DaemonSets,
+// ReplicaSets and Jobs, and custom workloads that follow the same
+// convention.
+//
+// Typed objects converted to unstructured often have no kind, these are
+// treated as Pods if they have containers in their spec.
+//
+// Kinds without pods have no containers.
+func Containers(obj unstructured.Unstructured) []Container {
+	var path []string
+	switch obj.GetKind() {
+	case "Pod":
+		path = []string{"spec"}
+	case "":
+		path = []string{"spec", "template", "spec"}
+		if _, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "containers"); ok {
+			path = []string{"spec"}
+		}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		path = []string{"spec", "template", "spec"}
+	}
+
+	var res []Container
+	res = append(res, containers(obj.Object, append(path, "containers"), false)...)
+	res = append(res, containers(obj.Object, append(path, "initContainers"), true)...)
+	return res
+}
+
+// Images returns the images of the containers of the pods of a workload,
+// excluding the init containers.
+func Images(obj unstructured.Unstructured) []string {
+	var res []string
+	for _, v := range Containers(obj) {
+		if !v.Init {
+			res = append(res, v.Image)
+		}
+	}
+	return res
+}
+
+func containers(obj map[string]any, path []string, init bool) []Container {
+	items, _, _ := unstructured.NestedSlice(obj, path...)
+	var res []Container
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(m, "name")
+		image, _, _ := unstructured.NestedString(m, "image")
+		res = append(res, Container{Name: name, Image: image, Init: init})
+	}
+	return res
+}
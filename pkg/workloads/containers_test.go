@@ -0,0 +1,120 @@
+package workloads
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/gitops-tools/apps-scanner/test"
+)
+
+var testPodSpec = corev1.PodSpec{
+	Containers: []corev1.Container{
+		{Name: "cart", Image: "weaveworksdemos/carts:0.4.8"},
+		{Name: "proxy", Image: "envoyproxy/envoy:v1.29.0"},
+	},
+	InitContainers: []corev1.Container{
+		{Name: "migrate", Image: "weaveworksdemos/carts-migrate:0.4.8"},
+	},
+}
+
+func TestContainers(t *testing.T) {
+	want := []Container{
+		{Name: "cart", Image: "weaveworksdemos/carts:0.4.8"},
+		{Name: "proxy", Image: "envoyproxy/envoy:v1.29.0"},
+		{Name: "migrate", Image: "weaveworksdemos/carts-migrate:0.4.8", Init: true},
+	}
+	template := corev1.PodTemplateSpec{Spec: testPodSpec}
+
+	containerTests := []struct {
+		name string
+		obj  runtime.Object
+		want []Container
+	}{
+		{
+			name: "deployment",
+			obj: &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				Spec:     appsv1.DeploymentSpec{Template: template},
+			},
+			want: want,
+		},
+		{
+			name: "statefulset",
+			obj: &appsv1.StatefulSet{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+				Spec:     appsv1.StatefulSetSpec{Template: template},
+			},
+			want: want,
+		},
+		{
+			name: "cronjob",
+			obj: &batchv1.CronJob{
+				TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "CronJob"},
+				Spec: batchv1.CronJobSpec{
+					JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: template}},
+				},
+			},
+			want: want,
+		},
+		{
+			name: "pod",
+			obj: &corev1.Pod{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+				Spec:     testPodSpec,
+			},
+			want: want,
+		},
+		{
+			name: "pod without a kind",
+			obj:  &corev1.Pod{Spec: testPodSpec},
+			want: want,
+		},
+		{
+			name: "deployment without a kind",
+			obj:  &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Template: template}},
+			want: want,
+		},
+		{
+			name: "kind without pods",
+			obj: &corev1.Service{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			},
+		},
+	}
+
+	for _, tt := range containerTests {
+		t.Run(tt.name, func(t *testing.T) {
+			containers := Containers(makeUnstructured(t, tt.obj))
+
+			if diff := cmp.Diff(tt.want, containers); diff != "" {
+				t.Fatalf("failed to get containers:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestImages(t *testing.T) {
+	obj := makeUnstructured(t, &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		Spec:     appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: testPodSpec}},
+	})
+
+	want := []string{"weaveworksdemos/carts:0.4.8", "envoyproxy/envoy:v1.29.0"}
+	if diff := cmp.Diff(want, Images(obj)); diff != "" {
+		t.Fatalf("failed to get images:\n%s", diff)
+	}
+}
+
+func makeUnstructured(t *testing.T, obj runtime.Object) unstructured.Unstructured {
+	t.Helper()
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	test.AssertNoError(t, err)
+	return unstructured.Unstructured{Object: m}
+}